@@ -0,0 +1,310 @@
+// Package cetd extract main content of html page by composite text density
+// (CETD-DS: content extraction via text density with density sum).
+// It has no package level state and is safe for concurrent use.
+package cetd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// ErrNoCandidate is returned when document has no content candidate
+var ErrNoCandidate = errors.New("cetd: no content candidate")
+
+type tree struct {
+	path       string
+	parent     *tree
+	children   []*tree
+	node       *html.Node
+	density    *density
+	densitySum int
+}
+
+type density struct {
+	chars    int
+	tags     int
+	linkchar int
+	linktag  int
+}
+
+// trees is density tree of one document, root is body element
+type trees struct {
+	root   *tree
+	byNode map[*html.Node]*tree
+}
+
+// Candidate is scored element of document
+type Candidate struct {
+	// Path is unique path of element, e.g. body/div[2]/article/p[5]
+	Path        string
+	Node        *html.Node
+	TextDensity int
+	DensitySum  int
+}
+
+// Extract return copy of doc subtree with highest density sum, descendants
+// with low density are pruned. doc isn't modified, boilerplate should be
+// removed by caller before, e.g. by clean.Default
+func Extract(doc *html.Node) (*html.Node, error) {
+	t := parse(doc)
+	c := t.candidat()
+	if c == nil {
+		return nil, ErrNoCandidate
+	}
+	return t.prune(c), nil
+}
+
+// Candidates return body elements of doc ordered by density sum.
+// doc isn't modified
+func Candidates(doc *html.Node) []Candidate {
+	t := parse(doc)
+	sl := t.sorted()
+	cands := make([]Candidate, len(sl))
+	for i, v := range sl {
+		cands[i] = Candidate{Path: v.path, Node: v.node, TextDensity: v.density.textDensity(), DensitySum: v.densitySum}
+	}
+	return cands
+}
+
+func (d *density) density() int {
+	if d.tags <= 0 {
+		return (d.chars)
+	}
+	return int(float32(d.chars) / float32(d.tags))
+}
+
+func (d *density) textDensity() int {
+	//text_density = (1.0 * char_num / tag_num) * qLn((1.0 * char_num * tag_num) / (1.0 * linkchar_num * linktag_num))
+	// / qLn(qLn(1.0 * char_num * linkchar_num / un_linkchar_num + ratio * char_num + qExp(1.0)));
+	//return 0
+
+	if d.chars == 0 {
+		return 0
+	}
+	// d is shared with parent's count, so it's not changed here
+	chars, tags, linkchar, linktag := d.chars, d.tags, d.linkchar, d.linktag
+	unlinkcharnum := chars - linkchar
+	if tags <= 0 {
+		tags = 1
+	}
+	if linkchar <= 0 {
+		linkchar = 1
+	}
+	if linktag <= 0 {
+		linktag = 1
+	}
+	if unlinkcharnum <= 0 {
+		unlinkcharnum = 1
+	}
+	chisl := (float64(chars) / float64(tags)) * math.Log((float64(chars)*float64(tags))/(float64(linkchar)*float64(linktag)))
+	//qLn(qLn(1.0*char_num*linkchar_num/un_linkchar_num + ratio*char_num + qExp(1.0)))
+	znamen := math.Log( /*math.Log*/ (float64(chars)*float64(linkchar)/float64(unlinkcharnum) + 1.0*float64(chars) + math.Exp(1.0)))
+	return int(chisl / znamen)
+}
+
+func (d *density) score() int {
+
+	if d.tags <= 0 {
+		d.tags = 1
+	}
+	if d.linkchar <= 0 {
+		d.linkchar = 1
+	}
+	if d.chars <= 0 {
+		d.chars = 1
+	}
+	//log.Println("log", math.Log2(float64(d.chars)/float64(d.hyper)), d.chars, d.hyper)
+	score := (float64(d.chars) / float64(d.tags)) * math.Log2(float64(d.chars)/float64(d.linkchar))
+	return int(score)
+}
+
+// calc compute density of every element and density sum of its subtree in
+// one bottom-up pass, counts of element are sums of its children counts
+func (t *trees) calc() {
+	var f func(*tree) int
+	f = func(tr *tree) int {
+		sum := 0
+		for _, c := range tr.children {
+			sum += f(c)
+		}
+		tr.density = t.count(tr.node)
+		tr.densitySum = sum + tr.density.textDensity()
+		return tr.densitySum
+	}
+	if t.root != nil {
+		f(t.root)
+	}
+}
+
+// count return chars and tags of n from its text children and densities of
+// its element children. Descendants of <a> are counted as link chars and tags
+func (t *trees) count(n *html.Node) *density {
+	d := &density{}
+	isLink := n.Data == "a"
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case html.TextNode:
+			if isLink {
+				d.linkchar += textChars(c.Data)
+			} else {
+				d.chars += textChars(c.Data)
+			}
+		case html.ElementNode:
+			cd := t.byNode[c].density
+			if isLink {
+				d.linkchar += cd.chars + cd.linkchar
+				d.linktag += 1 + cd.tags + cd.linktag
+			} else {
+				d.chars += cd.chars
+				d.linkchar += cd.linkchar
+				d.tags += 1 + cd.tags
+				d.linktag += cd.linktag
+			}
+		}
+	}
+	return d
+}
+
+// textChars return number of runes of trimmed text without line breaks
+func textChars(s string) int {
+	s = strings.TrimSpace(s)
+	// count runes, not bytes, so cyrillic and cjk text isn't overweighted
+	return utf8.RuneCountInString(s) - strings.Count(s, "\r\n") - strings.Count(s, "\n")
+}
+
+// sorted return all elements ordered by density sum
+func (t *trees) sorted() []*tree {
+	sl := make([]*tree, 0, len(t.byNode))
+	for _, v := range t.byNode {
+		sl = append(sl, v)
+	}
+	sort.SliceStable(sl, func(i, j int) bool {
+		if sl[i].densitySum == sl[j].densitySum {
+			return sl[i].path < sl[j].path
+		}
+		return sl[i].densitySum > sl[j].densitySum
+	})
+	return sl
+}
+
+// candidat return element with highest density sum except body itself
+func (t *trees) candidat() *tree {
+	for i, v := range t.sorted() {
+		if i > 9 {
+			break
+		}
+		if v != t.root {
+			return v
+		}
+	}
+	return nil
+}
+
+// parse build density tree of body elements
+func parse(node *html.Node) *trees {
+	t := &trees{byNode: make(map[*html.Node]*tree)}
+	var add func(n *html.Node, parent *tree, path string)
+	add = func(n *html.Node, parent *tree, path string) {
+		tr := &tree{path: path, parent: parent, node: n}
+		t.byNode[n] = tr
+		if parent != nil {
+			parent.children = append(parent.children, tr)
+		}
+		seen := make(map[string]int)
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode {
+				seen[c.Data]++
+			}
+		}
+		idx := make(map[string]int)
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			idx[c.Data]++
+			p := path + "/" + c.Data
+			if seen[c.Data] > 1 {
+				p += fmt.Sprintf("[%d]", idx[c.Data])
+			}
+			add(c, tr, p)
+		}
+	}
+	var find func(*html.Node) *html.Node
+	find = func(n *html.Node) *html.Node {
+		if n.Type == html.ElementNode && strings.ToLower(n.Data) == "body" {
+			return n
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if b := find(c); b != nil {
+				return b
+			}
+		}
+		return nil
+	}
+	if body := find(node); body != nil {
+		add(body, nil, "body")
+		t.root = t.byNode[body]
+	}
+	t.calc()
+	return t
+}
+
+// selected return candidate descendants with density sum above
+// 20% of candidate's one and all their descendants
+func (t *trees) selected(c *tree) map[*tree]bool {
+	threshold := int(float32(c.densitySum) * 0.2)
+	currents := make(map[*tree]bool)
+	var f func(*tree)
+	f = func(v *tree) {
+		if v.densitySum > threshold || currents[v.parent] {
+			currents[v] = true
+		}
+		for _, ch := range v.children {
+			f(ch)
+		}
+	}
+	f(c)
+	return currents
+}
+
+// prune return deep copy of candidate keeping selected elements, their text
+// and elements with selected descendants
+func (t *trees) prune(c *tree) *html.Node {
+	currents := t.selected(c)
+	var f func(*html.Node, bool) *html.Node
+	f = func(n *html.Node, parentSelected bool) *html.Node {
+		switch n.Type {
+		case html.TextNode:
+			if !parentSelected {
+				return nil
+			}
+			return &html.Node{Type: html.TextNode, Data: n.Data}
+		case html.ElementNode:
+		default:
+			return nil
+		}
+		sel := currents[t.byNode[n]]
+		cp := &html.Node{Type: n.Type, DataAtom: n.DataAtom, Data: n.Data, Namespace: n.Namespace}
+		cp.Attr = append(cp.Attr, n.Attr...)
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			if cc := f(ch, sel); cc != nil {
+				cp.AppendChild(cc)
+			}
+		}
+		if !sel && cp.FirstChild == nil {
+			return nil
+		}
+		return cp
+	}
+	root := f(c.node, true)
+	if root == nil {
+		root = &html.Node{Type: html.ElementNode, DataAtom: c.node.DataAtom, Data: c.node.Data}
+	}
+	return root
+}
@@ -0,0 +1,93 @@
+package cetd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/recoilme/readability/clean"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func parseFile(t *testing.T, p string) *html.Node {
+	f, err := os.Open(p)
+	assert.NoError(t, err)
+	defer f.Close()
+	n, err := html.Parse(f)
+	assert.NoError(t, err)
+	return n
+}
+
+func render(n *html.Node) string {
+	var buf bytes.Buffer
+	html.Render(&buf, n)
+	return buf.String()
+}
+
+func TestExtract(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			doc := parseFile(t, "../CECTD-DS/html/4.htm")
+			clean.Default.Clean(doc)
+			content, err := Extract(doc)
+			assert.NoError(t, err)
+			assert.True(t, strings.Contains(render(content), "Что это вообще такое"))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCandidatesPath(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body><div>menu</div><div><article>` +
+		`<p>first paragraph of text</p><p>second paragraph of text</p><p>third paragraph of text</p>` +
+		`</article></div></body></html>`))
+	assert.NoError(t, err)
+	paths := make(map[string]bool)
+	for _, c := range Candidates(doc) {
+		assert.False(t, paths[c.Path], c.Path)
+		paths[c.Path] = true
+	}
+	assert.True(t, paths["body/div[2]/article/p[3]"])
+	assert.True(t, paths["body/div[1]"])
+}
+
+func TestCandidatesScript(t *testing.T) {
+	layout := func(word string) string {
+		p := strings.Repeat(word+" ", 40)
+		return `<html><body><div><a href="/">` + word + `</a><a href="/">` + word + `</a></div>` +
+			`<div><h1>` + word + `</h1><p>` + p + `</p><p>` + p + `</p></div><div>` + word + `</div></body></html>`
+	}
+	var want []Candidate
+	for _, word := range []string{"latin", "кирил", "漢字漢字漢", "mixяз"} {
+		doc, err := html.Parse(strings.NewReader(layout(word)))
+		assert.NoError(t, err)
+		cands := Candidates(doc)
+		if want == nil {
+			want = cands
+			continue
+		}
+		assert.Len(t, cands, len(want), word)
+		for i := range cands {
+			assert.Equal(t, want[i].Path, cands[i].Path, word)
+			assert.Equal(t, want[i].TextDensity, cands[i].TextDensity, word)
+			assert.Equal(t, want[i].DensitySum, cands[i].DensitySum, word)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader("<html><body><div>Привет\n мир <a href=\"/\">ссылка <b>жирная</b></a>" +
+		"<p>абзац <i>текста</i></p></div></body></html>"))
+	assert.NoError(t, err)
+	tr := parse(doc)
+	div := tr.root.children[0]
+	assert.Equal(t, density{chars: 21, tags: 3, linkchar: 12, linktag: 1}, *div.density)
+	a := div.children[0]
+	assert.Equal(t, density{linkchar: 12, linktag: 1}, *a.density)
+}
@@ -0,0 +1,150 @@
+// Package clean remove boilerplate elements from html document before
+// content extraction. Cleaner is immutable and safe for concurrent use.
+package clean
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// Rules describe removed elements
+type Rules struct {
+	// Tags are names of removed elements, e.g. script, nav
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Selectors are css selectors of removed elements, e.g. .share-buttons, [role=complementary]
+	Selectors []string `json:"selectors,omitempty" yaml:"selectors,omitempty"`
+	// Pattern is regexp matched against class and id of removed elements, e.g. sidebar|promo|related
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// Comments removes html comments
+	Comments bool `json:"comments,omitempty" yaml:"comments,omitempty"`
+}
+
+// DefaultRules remove scripts, styles, navigation, text inputs and comments.
+// Forms are kept, ASP.NET pages wrap whole body in one
+var DefaultRules = Rules{
+	Tags:     []string{"style", "script", "svg", "nav", "aside", "noscript", "xmp", "textarea", "air"},
+	Comments: true,
+}
+
+// Default is cleaner with DefaultRules
+var Default = mustNew(DefaultRules)
+
+// keep are never removed, pattern like "sidebar" often matches body class
+var keep = map[string]bool{"html": true, "head": true, "body": true}
+
+// Cleaner is compiled Rules
+type Cleaner struct {
+	tags      map[string]bool
+	selectors []cascadia.Selector
+	pattern   *regexp.Regexp
+	comments  bool
+}
+
+// New compile rules, it fails on bad selector or pattern
+func New(r Rules) (*Cleaner, error) {
+	c := &Cleaner{tags: make(map[string]bool, len(r.Tags)), comments: r.Comments}
+	for _, t := range r.Tags {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			c.tags[t] = true
+		}
+	}
+	for _, s := range r.Selectors {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		sel, err := cascadia.Compile(s)
+		if err != nil {
+			return nil, fmt.Errorf("clean: bad selector %q: %w", s, err)
+		}
+		c.selectors = append(c.selectors, sel)
+	}
+	if r.Pattern != "" {
+		re, err := regexp.Compile("(?i)" + r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("clean: bad pattern %q: %w", r.Pattern, err)
+		}
+		c.pattern = re
+	}
+	return c, nil
+}
+
+func mustNew(r Rules) *Cleaner {
+	c, err := New(r)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Merge return rules with tags and selectors of both, pattern of other is
+// joined as alternative and comments are removed if any of rules removes them
+func (r Rules) Merge(other Rules) Rules {
+	m := Rules{Comments: r.Comments || other.Comments}
+	m.Tags = append(append(m.Tags, r.Tags...), other.Tags...)
+	m.Selectors = append(append(m.Selectors, r.Selectors...), other.Selectors...)
+	switch {
+	case r.Pattern == "":
+		m.Pattern = other.Pattern
+	case other.Pattern == "":
+		m.Pattern = r.Pattern
+	default:
+		m.Pattern = "(?:" + r.Pattern + ")|(?:" + other.Pattern + ")"
+	}
+	return m
+}
+
+// Match report whether n is removed by cleaner
+func (c *Cleaner) Match(n *html.Node) bool {
+	switch n.Type {
+	case html.CommentNode:
+		return c.comments
+	case html.ElementNode:
+	default:
+		return false
+	}
+	name := strings.ToLower(n.Data)
+	if keep[name] {
+		return false
+	}
+	if c.tags[name] {
+		return true
+	}
+	if c.pattern != nil {
+		for _, a := range n.Attr {
+			if (a.Key == "class" || a.Key == "id") && a.Val != "" && c.pattern.MatchString(a.Val) {
+				return true
+			}
+		}
+	}
+	for _, sel := range c.selectors {
+		if sel.Match(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// Clean remove matched elements with their content from n in one pass
+// and return number of removed nodes
+func (c *Cleaner) Clean(n *html.Node) int {
+	removed := 0
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		for ch := n.FirstChild; ch != nil; {
+			next := ch.NextSibling
+			if c.Match(ch) {
+				n.RemoveChild(ch)
+				removed++
+			} else {
+				f(ch)
+			}
+			ch = next
+		}
+	}
+	f(n)
+	return removed
+}
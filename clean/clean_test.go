@@ -0,0 +1,49 @@
+package clean
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func render(n *html.Node) string {
+	var buf bytes.Buffer
+	html.Render(&buf, n)
+	return buf.String()
+}
+
+func TestDefault(t *testing.T) {
+	f, err := os.Open("../CECTD-DS/html/3.htm")
+	assert.NoError(t, err)
+	defer f.Close()
+	n, err := html.Parse(f)
+	assert.NoError(t, err)
+
+	Default.Clean(n)
+	ss := render(n)
+	assert.NotContains(t, ss, "<script")
+	assert.NotContains(t, ss, "<!--")
+}
+
+func TestClean(t *testing.T) {
+	c, err := New(Rules{
+		Tags:      []string{"script"},
+		Selectors: []string{".share-buttons", "[role=complementary]", "#comments"},
+		Pattern:   "sidebar|promo|related",
+	})
+	assert.NoError(t, err)
+	doc, err := html.Parse(strings.NewReader(`<html><body class="has-sidebar"><article><p>text</p>` +
+		`<script>x()</script><div class="share-buttons">share</div><div role="complementary">more</div>` +
+		`<section id="comments">comments</section><ul class="Related-Posts"><li>post</li></ul>` +
+		`<div id="main-promo">buy</div><p>end</p></article><!-- c --></body></html>`))
+	assert.NoError(t, err)
+	assert.Equal(t, 6, c.Clean(doc))
+	assert.Equal(t, `<html><head></head><body class="has-sidebar"><article><p>text</p><p>end</p></article><!-- c --></body></html>`, render(doc))
+
+	_, err = New(Rules{Selectors: []string{"div["}})
+	assert.Error(t, err)
+}
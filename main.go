@@ -2,16 +2,22 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/dyatlov/go-htmlinfo/htmlinfo"
+	"github.com/recoilme/readability/clean"
+	"github.com/recoilme/readability/site"
 	"github.com/recoilme/readability/web"
 )
 
@@ -19,18 +25,113 @@ func main() {
 	host := flag.String("host", "localhost", "Host to listen on")
 	port := flag.Int("port", 8000, "Port to listen on")
 	waitTimeout := flag.Int("wait_timeout", 7, "How much time to wait for/fetch response from remote server")
+	acceptLanguage := flag.String("accept_language", "", "Accept-Language header sent to remote servers")
+	profiles := flag.String("profiles", "yandexbot,iphone", "Comma separated header profiles tried in order when blocked")
+	retries := flag.Int("retries", 0, "Max fetch attempts on 5xx and network errors, 0 disables retries")
+	maxBodySize := flag.Int64("max_body_size", 10<<20, "Max size of remote response body in bytes")
+	compression := flag.Bool("compression", false, "Request compressed responses from remote servers")
+	allowPrivate := flag.Bool("allow_private", false, "Allow fetching loopback, private and link-local addresses")
+	denyCIDR := flag.String("deny_cidr", "", "Comma separated additionally blocked networks, e.g. 203.0.113.0/24")
+	allowHosts := flag.String("allow_hosts", "", "Comma separated hosts allowed to fetch, all if empty")
+	denyHosts := flag.String("deny_hosts", "", "Comma separated hosts forbidden to fetch")
+	schemes := flag.String("schemes", "http,https", "Comma separated allowed url schemes")
+	maxRedirects := flag.Int("max_redirects", 10, "Max redirects to follow, negative disables redirects")
+	hostProfiles := flag.String("host_profiles", "", "Per host profiles, e.g. example.com=googlebot;example.org=desktop,iphone")
+	rulesDir := flag.String("rules", "", "Directory with site rules, *.json, *.yaml or *.yml per site")
+	rulesReload := flag.Int("rules_reload", 30, "How often in seconds to reload changed site rules, 0 disables reload")
 
 	flag.Parse()
 
-	startServer(*host, *port, *waitTimeout)
+	guard := web.NewGuard()
+	guard.AllowPrivate = *allowPrivate
+	guard.Schemes = splitList(*schemes)
+	guard.AllowHosts = splitList(*allowHosts)
+	guard.DenyHosts = splitList(*denyHosts)
+	for _, cidr := range splitList(*denyCIDR) {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		guard.BlockedNets = append(guard.BlockedNets, n)
+	}
+	fetcher := web.NewFetcher(&http.Client{})
+	fetcher.Guard = guard
+	fetcher.MaxRedirects = *maxRedirects
+	fetcher.Timeout = time.Duration(*waitTimeout) * time.Second
+	fetcher.AcceptLanguage = *acceptLanguage
+	fetcher.MaxBodySize = *maxBodySize
+	fetcher.Compression = *compression
+	if *retries > 1 {
+		fetcher.Retry = web.DefaultRetryPolicy()
+		fetcher.Retry.MaxAttempts = *retries
+		// every attempt has own timeout, deadline leaves room for all of them and backoffs
+		fetcher.Retry.Deadline = time.Duration(*retries)*fetcher.Timeout + time.Duration(*retries-1)*fetcher.Retry.MaxBackoff
+	}
+	var err error
+	if fetcher.Profiles, err = parseProfiles(*profiles); err != nil {
+		log.Fatal(err)
+	}
+	if *hostProfiles != "" {
+		fetcher.HostProfiles = make(map[string][]web.Profile)
+		for _, hp := range strings.Split(*hostProfiles, ";") {
+			kv := strings.SplitN(hp, "=", 2)
+			if len(kv) != 2 {
+				log.Fatalf("bad host_profiles: %s", hp)
+			}
+			if fetcher.HostProfiles[strings.ToLower(kv[0])], err = parseProfiles(kv[1]); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	if *rulesDir != "" {
+		if fetcher.Sites, err = site.Load(*rulesDir); err != nil {
+			log.Fatal(err)
+		}
+		if *rulesReload > 0 {
+			go fetcher.Sites.Watch(context.Background(), time.Duration(*rulesReload)*time.Second, func(err error) {
+				log.Printf("rules reload: %s", err)
+			})
+		}
+	}
+
+	startServer(*host, *port, *waitTimeout, fetcher)
+}
+
+// splitList split comma separated flag value skipping empty items
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// parseProfiles return known profiles by comma separated names
+func parseProfiles(names string) ([]web.Profile, error) {
+	var profiles []web.Profile
+	for _, name := range splitList(names) {
+		p, ok := web.KnownProfiles[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile: %s", name)
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
 }
 
-func startServer(host string, port int, waitTimeout int) {
+func startServer(host string, port int, waitTimeout int, fetcher *web.Fetcher) {
+	writeTimeout := time.Duration(waitTimeout) * time.Second
+	if fetcher.Retry != nil {
+		// response is written after all fetch attempts
+		writeTimeout += fetcher.Retry.Deadline
+	}
 	s := &http.Server{
 		Addr:           fmt.Sprintf("%s:%d", host, port),
-		Handler:        &apiHandler{},
+		Handler:        &apiHandler{fetcher: fetcher},
 		ReadTimeout:    time.Duration(waitTimeout) * time.Second,
-		WriteTimeout:   time.Duration(waitTimeout) * time.Second,
+		WriteTimeout:   writeTimeout,
 		MaxHeaderBytes: 1 << 20,
 	}
 
@@ -38,24 +139,25 @@ func startServer(host string, port int, waitTimeout int) {
 }
 
 type apiHandler struct {
+	fetcher *web.Fetcher
 }
 
 func (h *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	u := r.FormValue("info")
 	if u != "" {
-		urlInfo(w, r, u)
+		h.urlInfo(w, r, u)
 		return
 	}
 
 	u = r.FormValue("content")
 	if u != "" {
-		content(w, r, u)
+		h.content(w, r, u)
 		return
 	}
 }
 
-func urlInfo(w http.ResponseWriter, r *http.Request, u string) {
+func (h *apiHandler) urlInfo(w http.ResponseWriter, r *http.Request, u string) {
 	w.Header().Set("Content-Type", "application/json")
 
 	// to be able to retrieve data from javascript directly
@@ -71,46 +173,163 @@ func urlInfo(w http.ResponseWriter, r *http.Request, u string) {
 
 	log.Printf("Sending url: %s", u)
 
-	w.WriteHeader(http.StatusOK)
+	info, err := h.getHTMLInfo(r.Context(), u)
+	if err != nil {
+		renderError(err, w)
+		return
+	}
 	type res struct {
 		URL  string             `json:"url"`
 		Info *htmlinfo.HTMLInfo `json:"info"`
 	}
-	info := getHTMLInfo(u)
 	resp := &res{URL: u, Info: info}
 	b, err := json.Marshal(resp)
 	if err != nil {
 		renderError(err, w)
 		return
 	}
+	w.WriteHeader(http.StatusOK)
 	fmt.Fprintln(w, string(b))
 }
 
 func renderError(err error, w http.ResponseWriter) {
 	if err != nil {
-		http.Error(w, "{\"status\": \"error\", \"message\":\""+err.Error()+"\"}", 422)
+		b, _ := json.Marshal(map[string]string{"status": "error", "message": err.Error()})
+		http.Error(w, string(b), errorStatus(err))
 		return
 	}
 }
 
-func getHTMLInfo(url string) *htmlinfo.HTMLInfo {
+// errorStatus map fetch and extraction errors to http status codes
+func errorStatus(err error) int {
+	var se *web.HTTPStatusError
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &se):
+		if se.StatusCode == http.StatusNotFound || se.StatusCode == http.StatusGone {
+			return se.StatusCode
+		}
+		return http.StatusBadGateway
+	case errors.Is(err, web.ErrBlocked):
+		return http.StatusForbidden
+	case errors.Is(err, web.ErrTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, web.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, web.ErrNotHTML):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, web.ErrRead), errors.As(err, &dnsErr):
+		return http.StatusBadGateway
+	}
+	return http.StatusUnprocessableEntity
+}
+
+func (h *apiHandler) getHTMLInfo(ctx context.Context, url string) (*htmlinfo.HTMLInfo, error) {
 	info := htmlinfo.NewHTMLInfo()
-	b, _ := web.Get(url, 0, "")
-	info.Parse(bytes.NewReader(b), &url, nil)
-	return info
+	resp, err := h.fetcher.Fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	info.Parse(bytes.NewReader(resp.Body), &url, nil)
+	return info, nil
 }
 
-func content(w http.ResponseWriter, r *http.Request, u string) {
-	w.Header().Set("Content-Type", "text/html")
+// readOptions build readability options from query parameters:
+// tags=figure,figcaption adds kept tags, only_tags replaces them,
+// attrs=img:alt,srcset adds kept attributes (may be repeated),
+// min_text_length and retry_length override thresholds,
+// engine=readability|cetd|ensemble selects extractor,
+// strip=.share-buttons,#comments, strip_tags=footer and strip_pattern=sidebar|promo
+// remove boilerplate in addition to default rules
+func readOptions(r *http.Request) ([]web.Option, error) {
+	var opts []web.Option
+	if v := r.FormValue("engine"); v != "" {
+		e, ok := web.Extractors[v]
+		if !ok {
+			return nil, fmt.Errorf("unknown engine: %s", v)
+		}
+		opts = append(opts, web.WithExtractor(e))
+	}
+	if v := r.FormValue("only_tags"); v != "" {
+		opts = append(opts, web.WithWhitelistTags(strings.Split(v, ",")...))
+	}
+	if v := r.FormValue("tags"); v != "" {
+		opts = append(opts, web.WithTags(strings.Split(v, ",")...))
+	}
+	for _, v := range r.Form["attrs"] {
+		kv := strings.SplitN(v, ":", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("bad attrs: %s", v)
+		}
+		opts = append(opts, web.WithAttrs(kv[0], strings.Split(kv[1], ",")...))
+	}
+	if v := r.FormValue("min_text_length"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, web.WithMinTextLength(n))
+	}
+	if v := r.FormValue("retry_length"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, web.WithRetryLength(n))
+	}
+	if r.FormValue("strip") != "" || r.FormValue("strip_tags") != "" || r.FormValue("strip_pattern") != "" {
+		rules := clean.Rules{Tags: splitList(r.FormValue("strip_tags")), Pattern: r.FormValue("strip_pattern")}
+		if v := r.FormValue("strip"); v != "" {
+			rules.Selectors = []string{v}
+		}
+		c, err := clean.New(clean.DefaultRules.Merge(rules))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, web.WithCleaner(c))
+	}
+	return opts, nil
+}
 
-	content := web.GetContent(u)
-	domain := ""
-	parsed, err := url.Parse(u)
-	if err == nil {
-		domain = parsed.Scheme + "://" + parsed.Host + "/"
+func (h *apiHandler) content(w http.ResponseWriter, r *http.Request, u string) {
+	opts, err := readOptions(r)
+	if err != nil {
+		renderError(err, w)
+		return
+	}
+	a, err := h.fetcher.GetContent(r.Context(), u, opts...)
+	if err != nil {
+		log.Printf("content %s: %s", u, err)
+		renderError(err, w)
+		return
 	}
-	content = strings.Replace(content, "<img src=\"/", "<br/><img src=\""+domain, -1)
-	content = strings.Replace(content, "<head>", "<head><meta charset=\"utf-8\"><link rel=\"stylesheet\" href=\"https://cdn.jsdelivr.net/gh/kognise/water.css@latest/dist/dark.min.css\"/>", -1)
+	switch r.FormValue("format") {
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET")
+		b, err := json.Marshal(a)
+		if err != nil {
+			renderError(err, w)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, string(b))
+		return
+	case "md", "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, a.Markdown())
+		return
+	case "text", "txt":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, a.Text)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+
+	content := strings.Replace(a.Content, "<head>", "<head><meta charset=\"utf-8\"><link rel=\"stylesheet\" href=\"https://cdn.jsdelivr.net/gh/kognise/water.css@latest/dist/dark.min.css\"/>", -1)
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintln(w, content)
 
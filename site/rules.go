@@ -0,0 +1,154 @@
+package site
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rules are site rules loaded from directory, safe for concurrent use
+type Rules struct {
+	// Dir is directory with rule files
+	Dir string
+
+	mu    sync.RWMutex
+	hosts map[string]*Rule
+	stamp string
+}
+
+// Load read all rule files from dir
+func Load(dir string) (*Rules, error) {
+	r := &Rules{Dir: dir}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Parse decode rule from json or yaml by file extension and compile its selectors
+func Parse(name string, b []byte) (*Rule, error) {
+	rule := &Rule{}
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		err = json.Unmarshal(b, rule)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, rule)
+	default:
+		err = fmt.Errorf("unknown format")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("site: %s: %w", name, err)
+	}
+	if len(rule.Hosts) == 0 {
+		rule.Hosts = []string{hostFromFile(name)}
+	}
+	if err = rule.compile(); err != nil {
+		return nil, fmt.Errorf("site: %s: %w", name, err)
+	}
+	return rule, nil
+}
+
+// files return sorted rule files of dir and stamp changed on any modification
+func (r *Rules) files() ([]string, string, error) {
+	entries, err := os.ReadDir(r.Dir)
+	if err != nil {
+		return nil, "", err
+	}
+	var files []string
+	var stamp strings.Builder
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, filepath.Join(r.Dir, e.Name()))
+		fmt.Fprintf(&stamp, "%s:%d:%d;", e.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	sort.Strings(files)
+	return files, stamp.String(), nil
+}
+
+// Reload read rules again if directory changed and report whether they were replaced.
+// Old rules are kept on error
+func (r *Rules) Reload() (bool, error) {
+	files, stamp, err := r.files()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	same := r.hosts != nil && stamp == r.stamp
+	r.mu.RUnlock()
+	if same {
+		return false, nil
+	}
+	hosts := make(map[string]*Rule)
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return false, err
+		}
+		rule, err := Parse(f, b)
+		if err != nil {
+			return false, err
+		}
+		for _, h := range rule.Hosts {
+			hosts[h] = rule
+		}
+	}
+	r.mu.Lock()
+	r.hosts, r.stamp = hosts, stamp
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Watch reload rules every interval until ctx is done, errors are passed to onErr if not nil
+func (r *Rules) Watch(ctx context.Context, every time.Duration, onErr func(error)) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if _, err := r.Reload(); err != nil && onErr != nil {
+				onErr(err)
+			}
+		}
+	}
+}
+
+// Lookup return rule of host or its nearest parent domain, nil if there is no rule.
+// It is safe to call on nil Rules
+func (r *Rules) Lookup(host string) *Rule {
+	if r == nil {
+		return nil
+	}
+	host = normHost(host)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for host != "" {
+		if rule, ok := r.hosts[host]; ok {
+			return rule
+		}
+		i := strings.Index(host, ".")
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestRules(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "example.com.yaml"), []byte(`
+content: [".post-body"]
+strip: [".share"]
+title: ["h1.headline"]
+date: ["time.published"]
+next_page: [".pager .next"]
+headers:
+  Cookie: consent=1
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "other.json"), []byte(`{"hosts": ["www.example.org"], "content": ["article"]}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte(`not a rule`), 0644))
+
+	r, err := Load(dir)
+	assert.NoError(t, err)
+	rule := r.Lookup("news.example.com:8080")
+	if assert.NotNil(t, rule) {
+		assert.Equal(t, "consent=1", rule.Headers["Cookie"])
+		page, _ := html.Parse(strings.NewReader(`<h1 class="headline">Title <b>here</b></h1>` +
+			`<time class="published" datetime="2024-05-12">May 12</time><div class="post-body">text<div class="share">x</div></div>` +
+			`<div class="pager"><span class="next"><a href="/p/2">next</a></span></div>`))
+		rule.Clean(page)
+		assert.Len(t, rule.ContentNodes(page), 1)
+		assert.Equal(t, "Title here", rule.TitleText(page))
+		assert.Equal(t, "2024-05-12", rule.DateText(page))
+		assert.Equal(t, "/p/2", rule.NextURL(page))
+		assert.Nil(t, findClass(page, "share"))
+	}
+	assert.NotNil(t, r.Lookup("example.org"))
+	assert.Nil(t, r.Lookup("example.net"))
+
+	// hot reload keeps old rules on error and picks up fixed files
+	bad := filepath.Join(dir, "bad.json")
+	assert.NoError(t, os.WriteFile(bad, []byte(`{"content": ["div["]}`), 0644))
+	_, err = r.Reload()
+	assert.Error(t, err)
+	assert.NotNil(t, r.Lookup("example.com"))
+	assert.NoError(t, os.WriteFile(bad, []byte(`{"content": ["div"]}`), 0644))
+	os.Chtimes(bad, time.Now().Add(time.Second), time.Now().Add(time.Second))
+	changed, err := r.Reload()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.NotNil(t, r.Lookup("bad"))
+}
+
+func findClass(n *html.Node, class string) *html.Node {
+	if n.Type == html.ElementNode && attr(n, "class") == class {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if r := findClass(c, class); r != nil {
+			return r
+		}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Package site load per domain extraction rules from directory.
+// Every file *.json, *.yaml or *.yml in directory is one Rule, rule applies to
+// its Hosts or, if Hosts are empty, to host named by file, e.g. example.com.yaml
+package site
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/recoilme/readability/clean"
+	"golang.org/x/net/html"
+)
+
+// Rule is extraction rule of one site, selectors are css selectors tried in order
+type Rule struct {
+	// Hosts are domains rule applies to, subdomains included
+	Hosts []string `json:"hosts,omitempty" yaml:"hosts,omitempty"`
+	// Content selects article body, all elements matched by first matching selector are taken
+	Content []string `json:"content,omitempty" yaml:"content,omitempty"`
+	// Strip selects elements removed before extraction
+	Strip []string `json:"strip,omitempty" yaml:"strip,omitempty"`
+	// StripPattern is regexp of class and id of elements removed before extraction
+	StripPattern string   `json:"strip_pattern,omitempty" yaml:"strip_pattern,omitempty"`
+	Title        []string `json:"title,omitempty" yaml:"title,omitempty"`
+	Author       []string `json:"author,omitempty" yaml:"author,omitempty"`
+	Date         []string `json:"date,omitempty" yaml:"date,omitempty"`
+	// NextPage selects link to next page of multi page article
+	NextPage []string `json:"next_page,omitempty" yaml:"next_page,omitempty"`
+	// MaxPages limits number of followed pages, 10 if zero
+	MaxPages int `json:"max_pages,omitempty" yaml:"max_pages,omitempty"`
+	// Headers override request headers, e.g. User-Agent or Cookie
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	content, title, author, date, next []cascadia.Selector
+	cleaner                            *clean.Cleaner
+}
+
+const defMaxPages = 10
+
+var linkSel = cascadia.MustCompile("a[href]")
+
+// compile parse selectors of rule
+func (r *Rule) compile() (err error) {
+	comp := func(sels []string) ([]cascadia.Selector, error) {
+		var res []cascadia.Selector
+		for _, s := range sels {
+			sel, err := cascadia.Compile(s)
+			if err != nil {
+				return nil, fmt.Errorf("site: bad selector %q: %w", s, err)
+			}
+			res = append(res, sel)
+		}
+		return res, nil
+	}
+	if r.content, err = comp(r.Content); err != nil {
+		return err
+	}
+	if r.title, err = comp(r.Title); err != nil {
+		return err
+	}
+	if r.author, err = comp(r.Author); err != nil {
+		return err
+	}
+	if r.date, err = comp(r.Date); err != nil {
+		return err
+	}
+	if r.next, err = comp(r.NextPage); err != nil {
+		return err
+	}
+	if len(r.Strip) > 0 || r.StripPattern != "" {
+		if r.cleaner, err = clean.New(clean.Rules{Selectors: r.Strip, Pattern: r.StripPattern}); err != nil {
+			return err
+		}
+	}
+	for i, h := range r.Hosts {
+		r.Hosts[i] = normHost(h)
+	}
+	return nil
+}
+
+// Pages return max number of pages to follow
+func (r *Rule) Pages() int {
+	if r.MaxPages <= 0 {
+		return defMaxPages
+	}
+	return r.MaxPages
+}
+
+// Clean remove strip elements from page
+func (r *Rule) Clean(page *html.Node) {
+	if r.cleaner != nil {
+		r.cleaner.Clean(page)
+	}
+}
+
+// ContentNodes return elements matched by first matching content selector
+func (r *Rule) ContentNodes(page *html.Node) []*html.Node {
+	for _, sel := range r.content {
+		if nodes := sel.MatchAll(page); len(nodes) > 0 {
+			return nodes
+		}
+	}
+	return nil
+}
+
+// TitleText return text of first matched title element
+func (r *Rule) TitleText(page *html.Node) string {
+	return value(page, r.title)
+}
+
+// AuthorText return text of first matched author element
+func (r *Rule) AuthorText(page *html.Node) string {
+	return value(page, r.author)
+}
+
+// DateText return datetime or content attribute or text of first matched date element
+func (r *Rule) DateText(page *html.Node) string {
+	return value(page, r.date, "datetime", "content")
+}
+
+// NextURL return href of first matched next page link
+func (r *Rule) NextURL(page *html.Node) string {
+	for _, sel := range r.next {
+		if n := sel.MatchFirst(page); n != nil {
+			if href := attr(n, "href"); href != "" {
+				return href
+			}
+			// selector may point to element wrapping the link
+			if a := linkSel.MatchFirst(n); a != nil {
+				return attr(a, "href")
+			}
+		}
+	}
+	return ""
+}
+
+// value return first non empty attribute of attrs or text of first matched element
+func value(page *html.Node, sels []cascadia.Selector, attrs ...string) string {
+	for _, sel := range sels {
+		n := sel.MatchFirst(page)
+		if n == nil {
+			continue
+		}
+		for _, a := range attrs {
+			if v := strings.TrimSpace(attr(n, a)); v != "" {
+				return v
+			}
+		}
+		if v := strings.Join(strings.Fields(text(n)), " "); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func text(n *html.Node) string {
+	var sb strings.Builder
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(n)
+	return sb.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// normHost lower host and strip port and www prefix
+func normHost(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	if i := strings.LastIndex(h, ":"); i > 0 && !strings.Contains(h[i:], "]") {
+		h = h[:i]
+	}
+	return strings.TrimPrefix(h, "www.")
+}
+
+// hostFromFile return host named by rule file, e.g. example.com.yaml
+func hostFromFile(name string) string {
+	base := filepath.Base(name)
+	return normHost(strings.TrimSuffix(base, filepath.Ext(base)))
+}
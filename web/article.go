@@ -0,0 +1,201 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Article is a readable representation of a web page
+type Article struct {
+	URL           string    `json:"url"`
+	FinalURL      string    `json:"final_url"`
+	CanonicalURL  string    `json:"canonical_url"`
+	Title         string    `json:"title"`
+	Byline        string    `json:"byline,omitempty"`
+	Authors       []Author  `json:"authors,omitempty"`
+	SiteName      string    `json:"site_name,omitempty"`
+	PublishedTime time.Time `json:"published_time,omitzero"`
+	// DateSource is where PublishedTime came from: site, json-ld, meta, microdata, time, text or url
+	DateSource string `json:"date_source,omitempty"`
+	// DateConfidence is confidence of PublishedTime from 0 to 1
+	DateConfidence float64   `json:"date_confidence,omitempty"`
+	ModifiedTime   time.Time `json:"modified_time,omitzero"`
+	Language       string    `json:"language,omitempty"`
+	Description    string    `json:"description,omitempty"`
+	Excerpt        string    `json:"excerpt,omitempty"`
+	Content        string    `json:"content"`
+	Text           string    `json:"text"`
+	WordCount      int       `json:"word_count"`
+	LeadImage      string    `json:"lead_image,omitempty"`
+	Images         []string  `json:"images,omitempty"`
+	Tags           []string  `json:"tags,omitempty"`
+	// Profile is name of header profile used to fetch page
+	Profile string `json:"profile,omitempty"`
+	// Attempts is number of fetch attempts made
+	Attempts int `json:"attempts,omitempty"`
+	// Redirects are urls redirected from before FinalURL
+	Redirects []string `json:"redirects,omitempty"`
+	// Engine is name of extractor which produced content
+	Engine string `json:"engine"`
+	// Confidence is extractor confidence from 0 to 1
+	Confidence float64 `json:"confidence"`
+	// EngineScores are scores of all engines in ensemble mode
+	EngineScores map[string]float64 `json:"engine_scores,omitempty"`
+
+	doc *html.Node
+	// next is url of next page found by site rule
+	next string
+}
+
+// newArticle fill article from page metadata and extracted content document
+func newArticle(u, finalURL string, m *Metadata, body *html.Node) *Article {
+	a := &Article{
+		URL:            u,
+		FinalURL:       finalURL,
+		Title:          m.Title,
+		SiteName:       m.SiteName,
+		PublishedTime:  m.Published,
+		DateSource:     m.DateSource,
+		DateConfidence: m.DateConfidence,
+		ModifiedTime:   m.Modified,
+		Language:       m.Language,
+		Description:    m.Description,
+		Excerpt:        m.Description,
+		Tags:           m.Tags,
+		Content:        renderNode(body),
+		doc:            body,
+	}
+	a.CanonicalURL = resolveURL(finalURL, m.Canonical)
+	a.setAuthors(m.Authors)
+	for _, img := range m.Images {
+		a.Images = appendUniq(a.Images, resolveURL(finalURL, img))
+	}
+	a.Text = RenderText(body)
+	if len(a.Images) > 0 {
+		a.LeadImage = a.Images[0]
+	} else if img := findElement(body, "img"); img != nil {
+		a.LeadImage = attr(img, "src")
+	}
+	a.WordCount = len(strings.Fields(a.Text))
+	if a.Excerpt == "" {
+		a.Excerpt = excerpt(strings.TrimSpace(spaceRe.ReplaceAllString(a.Text, " ")), 200)
+	}
+	return a
+}
+
+// setAuthors set authors with resolved profile urls and byline of their names
+func (a *Article) setAuthors(authors []Author) {
+	a.Authors = nil
+	var names []string
+	for _, au := range authors {
+		if au.URL != "" {
+			au.URL = resolveURL(a.FinalURL, au.URL)
+		}
+		a.Authors = append(a.Authors, au)
+		names = append(names, au.Name)
+	}
+	a.Byline = strings.Join(names, ", ")
+}
+
+// appendPage move content of next page p to the end of article
+func (a *Article) appendPage(p *Article) {
+	body, pb := findElement(a.doc, "body"), findElement(p.doc, "body")
+	if body == nil || pb == nil {
+		return
+	}
+	for c := pb.FirstChild; c != nil; {
+		next := c.NextSibling
+		pb.RemoveChild(c)
+		body.AppendChild(c)
+		c = next
+	}
+	a.Content = renderNode(a.doc)
+	a.Text = RenderText(a.doc)
+	a.WordCount = len(strings.Fields(a.Text))
+	a.next = p.next
+}
+
+// renderNode render node to html string
+func renderNode(n *html.Node) string {
+	var sb strings.Builder
+	html.Render(&sb, n)
+	return sb.String()
+}
+
+// resolveURL resolve ref against base, base is returned if ref is empty or invalid
+func resolveURL(base, ref string) string {
+	if ref == "" {
+		return base
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return base
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return r.String()
+	}
+	return b.ResolveReference(r).String()
+}
+
+// nodeText return concatenated text of node without script and style
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			return
+		}
+		if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+		if n.Type == html.ElementNode && mdBlockTags[n.Data] {
+			sb.WriteString(" ")
+		}
+	}
+	f(n)
+	return sb.String()
+}
+
+// findElement return first element with tag name
+func findElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if r := findElement(c, tag); r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+// attr return attribute value or empty string
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// excerpt cut text on word boundary
+func excerpt(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	cut := string(r[:max])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
@@ -0,0 +1,296 @@
+package web
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Author is article author with optional profile url
+type Author struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// maxBylineLen is max length in runes of byline element text
+const maxBylineLen = 120
+
+var (
+	// class or id of byline elements
+	bylineClassRe = regexp.MustCompile(`(?i)byline|author`)
+	// class or id of elements which only look like bylines
+	notBylineClassRe = regexp.MustCompile(`(?i)comment|bio|avatar|about|related`)
+	// "By X", "Written by X", "Автор: X", "Текст: X"
+	bylineTextRe = regexp.MustCompile(`(?i)^(?:(?:written|posted|story|text)\s+)?by\s+(.+)$|^(?:автор[ыа]?|текст)\s*[:—–-]\s*(.+)$`)
+	// prefixes stripped from byline element text
+	bylinePrefixRe = regexp.MustCompile(`(?i)^(?:(?:written|posted|story|text)\s+)?by\s+|^(?:автор[ыа]?|текст)\s*[:—–-]?\s*`)
+	// byline text after name, e.g. date or position
+	bylineTailRe = regexp.MustCompile(`\s*(?:[|•·/]|\s[—–-]\s|\d).*$`)
+	// separators of several authors
+	authorSepRe = regexp.MustCompile(`(?i)\s*(?:,|&|\sand\s|\sи\s)\s*`)
+)
+
+// detectAuthors find byline in page by rel=author, itemprop=author, byline
+// classes and "By X" patterns, its authors are used if metadata has none.
+// It returns byline element found by markup or class, caller removes it from
+// page so it isn't duplicated in content. Text pattern bylines are kept
+func (m *Metadata) detectAuthors(page *html.Node) *html.Node {
+	body := findElement(page, "body")
+	if body == nil {
+		return nil
+	}
+	n, authors, explicit := findByline(body)
+	if len(m.Authors) == 0 {
+		m.Authors = authors
+	}
+	if !explicit {
+		return nil
+	}
+	return n
+}
+
+// findByline return byline element, its authors and whether it was found
+// by markup or class rather than by text pattern
+func findByline(body *html.Node) (*html.Node, []Author, bool) {
+	// rel=author and itemprop=author are explicit
+	var authors []Author
+	var first *html.Node
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type != html.ElementNode || n.Data == "script" || n.Data == "style" {
+			return
+		}
+		if hasToken(attr(n, "rel"), "author") || hasToken(attr(n, "itemprop"), "author") {
+			if a := authorOf(n); a.Name != "" {
+				authors = appendAuthor(authors, a)
+				if first == nil {
+					first = n
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(body)
+	if len(authors) > 0 {
+		return bylineOf(first, authors), authors, true
+	}
+
+	// classes and text patterns
+	var byline *html.Node
+	explicit := false
+	var g func(*html.Node) bool
+	g = func(n *html.Node) bool {
+		if n.Type != html.ElementNode || n.Data == "script" || n.Data == "style" {
+			return false
+		}
+		cls := attr(n, "class") + " " + attr(n, "id")
+		txt := normText(n)
+		if txt != "" && utf8.RuneCountInString(txt) <= maxBylineLen && !notBylineClassRe.MatchString(cls) {
+			byClass := bylineClassRe.MatchString(cls)
+			if byClass || (isTextBlock(n) && bylineTextRe.MatchString(txt)) {
+				// text pattern alone is weak, all its names must look like names
+				if authors = parseAuthors(n, txt, !byClass); len(authors) > 0 {
+					// class byline with other text, e.g. author bio, is kept
+					byline, explicit = n, byClass && bylineRest(n, authors) == ""
+					return true
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if g(c) {
+				return true
+			}
+		}
+		return false
+	}
+	g(body)
+	return byline, authors, explicit
+}
+
+// authorOf return author of rel=author link or itemprop=author element
+func authorOf(n *html.Node) Author {
+	a := Author{}
+	if hasAttr(n, "itemscope") {
+		if name := findNode(n, func(c *html.Node) bool { return hasToken(attr(c, "itemprop"), "name") }); name != nil {
+			a.Name = itemValue(name)
+		}
+		if u := findNode(n, func(c *html.Node) bool { return hasToken(attr(c, "itemprop"), "url") }); u != nil {
+			a.URL = itemValue(u)
+		}
+	}
+	if a.Name == "" {
+		a.Name = cleanName(normText(n))
+		if hasAttr(n, "content") {
+			a.Name = cleanName(attr(n, "content"))
+		}
+	}
+	if a.URL == "" {
+		if n.Data == "a" {
+			a.URL = attr(n, "href")
+		} else if link := findElement(n, "a"); link != nil {
+			a.URL = attr(link, "href")
+		}
+	}
+	a.URL = strings.TrimSpace(a.URL)
+	return a
+}
+
+// bylineOf return largest element around author element which has nothing
+// but byline, nil if author is mentioned inside prose
+func bylineOf(n *html.Node, authors []Author) *html.Node {
+	var b *html.Node
+	for p := n; p != nil && p.Type == html.ElementNode && p.Data != "body"; p = p.Parent {
+		if utf8.RuneCountInString(normText(p)) > maxBylineLen || bylineRest(p, authors) != "" {
+			break
+		}
+		b = p
+	}
+	if b != nil && !mdBlockTags[b.Data] && b.Parent != nil && bylineRest(b.Parent, authors) != "" {
+		// e.g. <p>Interview with <a rel="author">Jane Doe</a> about Go</p>
+		return nil
+	}
+	return b
+}
+
+// bylineWords are words of byline besides names and dates
+var bylineWords = map[string]bool{
+	"by": true, "and": true, "written": true, "posted": true, "story": true, "text": true,
+	"и": true, "автор": true, "авторы": true, "автора": true, "текст": true,
+}
+
+// bylineRest return text of n left after author names, dates, byline words
+// and punctuation are removed, it's empty for pure byline
+func bylineRest(n *html.Node, authors []Author) string {
+	txt := " " + normText(n) + " "
+	for _, a := range authors {
+		txt = strings.Replace(txt, a.Name, " ", -1)
+	}
+	var f func(*html.Node)
+	f = func(c *html.Node) {
+		if c.Type == html.ElementNode && c.Data == "time" {
+			if t := normText(c); t != "" {
+				txt = strings.Replace(txt, t, " ", -1)
+			}
+			return
+		}
+		for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+			f(cc)
+		}
+	}
+	f(n)
+	for _, re := range []*regexp.Regexp{dayMonthRe, monthDayRe, numericRe, isoRe} {
+		txt = re.ReplaceAllString(txt, " ")
+	}
+	var rest []string
+	for _, w := range strings.Fields(txt) {
+		w = strings.TrimFunc(w, func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) })
+		if w != "" && !bylineWords[strings.ToLower(w)] {
+			rest = append(rest, w)
+		}
+	}
+	return strings.Join(rest, " ")
+}
+
+// parseAuthors split byline text into authors, profile urls are taken from
+// links with author name. Parts not looking like names are skipped, in strict
+// mode any such part rejects the whole byline
+func parseAuthors(n *html.Node, txt string, strict bool) []Author {
+	if m := bylineTextRe.FindStringSubmatch(txt); m != nil {
+		txt = m[1] + m[2]
+	} else {
+		txt = bylinePrefixRe.ReplaceAllString(txt, "")
+	}
+	txt = bylineTailRe.ReplaceAllString(txt, "")
+	links := map[string]string{}
+	var f func(*html.Node)
+	f = func(c *html.Node) {
+		if c.Type == html.ElementNode && c.Data == "a" {
+			links[cleanName(normText(c))] = strings.TrimSpace(attr(c, "href"))
+			return
+		}
+		for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+			f(cc)
+		}
+	}
+	f(n)
+	var authors []Author
+	for _, name := range authorSepRe.Split(txt, -1) {
+		name = cleanName(name)
+		if !isName(name) {
+			if strict {
+				return nil
+			}
+			continue
+		}
+		authors = appendAuthor(authors, Author{Name: name, URL: links[name]})
+	}
+	return authors
+}
+
+// isName report whether s looks like person name: up to 5 words starting
+// with capital letter, e.g. "Jane Doe", "J. R. R. Tolkien", "Анна-Мария Иванова"
+func isName(s string) bool {
+	words := strings.Fields(s)
+	if len(words) == 0 || len(words) > 5 {
+		return false
+	}
+	if _, ok := months[strings.ToLower(strings.TrimSuffix(words[0], "."))]; ok && len(words) == 1 {
+		// date left after byline tail, e.g. "Jane Doe, May 12"
+		return false
+	}
+	for _, w := range words {
+		r, _ := utf8.DecodeRuneInString(w)
+		if !unicode.IsUpper(r) {
+			return false
+		}
+		if strings.ContainsAny(w, ".") && !strings.HasSuffix(w, ".") {
+			// sentence punctuation inside word
+			return false
+		}
+	}
+	return true
+}
+
+// cleanName strip byline prefix and punctuation from name
+func cleanName(s string) string {
+	s = bylinePrefixRe.ReplaceAllString(strings.TrimSpace(s), "")
+	s = strings.Trim(s, " ,.;:|•·—–-@")
+	if utf8.RuneCountInString(s) < 2 || len(strings.Fields(s)) > 5 {
+		return ""
+	}
+	return s
+}
+
+// appendAuthor append author with new name
+func appendAuthor(authors []Author, a Author) []Author {
+	for i, v := range authors {
+		if strings.EqualFold(v.Name, a.Name) {
+			if v.URL == "" {
+				authors[i].URL = a.URL
+			}
+			return authors
+		}
+	}
+	return append(authors, a)
+}
+
+// isTextBlock report whether n is paragraph like element without block children
+func isTextBlock(n *html.Node) bool {
+	switch n.Data {
+	case "p", "div", "span", "address", "em", "i", "small", "strong", "b":
+	default:
+		return false
+	}
+	return findNode(n, func(c *html.Node) bool { return mdBlockTags[c.Data] }) == nil
+}
+
+// normText return text of n with collapsed spaces
+func normText(n *html.Node) string {
+	return strings.TrimSpace(spaceRe.ReplaceAllString(nodeText(n), " "))
+}
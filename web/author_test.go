@@ -0,0 +1,88 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/recoilme/readability/site"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestDetectAuthors(t *testing.T) {
+	for _, c := range []struct {
+		page    string
+		authors []Author
+	}{
+		{`<p>By <a rel="author" href="/u/jane">Jane Doe</a> | May 12, 2024</p>`, []Author{{"Jane Doe", "/u/jane"}}},
+		{`<div itemprop="author" itemscope><span itemprop="name">Иван Петров</span><link itemprop="url" href="/authors/ivan"></div>`,
+			[]Author{{"Иван Петров", "/authors/ivan"}}},
+		{`<div class="article-byline">By <a href="/a/1">John Smith</a> and Jane Doe — 12 May 2024</div>`,
+			[]Author{{"John Smith", "/a/1"}, {"Jane Doe", ""}}},
+		{`<p>Автор: Мария Иванова</p><p>Текст статьи.</p>`, []Author{{"Мария Иванова", ""}}},
+		{`<p>By 2020, the company had grown a lot.</p>`, nil},
+		{`<p>By the end of the year, sales rose sharply.</p>`, nil},
+		{`<p>By contrast, the new model is faster.</p>`, nil},
+		{`<p>Текст: программа работает быстрее.</p>`, nil},
+		{`<div class="author-bio">Jane Doe writes about Go</div>`, nil},
+	} {
+		page, _ := html.Parse(strings.NewReader(`<html><body>` + c.page + `</body></html>`))
+		m := &Metadata{}
+		m.detectAuthors(page)
+		assert.Equal(t, c.authors, m.Authors, c.page)
+	}
+
+	// text pattern bylines are kept in page, class bylines are returned for removal
+	page, _ := html.Parse(strings.NewReader(`<html><body><p>By Jane Doe</p></body></html>`))
+	m := &Metadata{}
+	assert.Nil(t, m.detectAuthors(page))
+	assert.Equal(t, []Author{{"Jane Doe", ""}}, m.Authors)
+	page, _ = html.Parse(strings.NewReader(`<html><body><p class="byline">By Jane Doe</p></body></html>`))
+	assert.NotNil(t, (&Metadata{}).detectAuthors(page))
+}
+
+func TestExtractAuthors(t *testing.T) {
+	s := pageWith(`<script type="application/ld+json">{"@type": "BlogPosting",
+"author": {"@type": "Person", "name": "Jane Doe", "sameAs": ["https://twitter.com/jane"]}}</script>`,
+		`<p class="byline">By Jane Doe</p>`)
+	base, _ := url.Parse("https://example.com/post")
+
+	a, err := ExtractFromHTML(s, base, WithExtractor(CETD{}))
+	assert.NoError(t, err)
+	assert.Equal(t, []Author{{"Jane Doe", "https://twitter.com/jane"}}, a.Authors)
+	assert.Equal(t, "Jane Doe", a.Byline)
+	assert.NotContains(t, a.Text, "By Jane Doe")
+}
+
+func TestExtractAuthorInProse(t *testing.T) {
+	s := pageWith("", `<p>Interview with <a rel="author" href="/u/j">Jane Doe</a> about Go modules.</p>`+
+		`<div class="meta"><span itemprop="author">John Smith</span> <span>share</span></div>`)
+
+	a, err := ExtractFromHTML(s, nil, WithExtractor(CETD{}))
+	assert.NoError(t, err)
+	assert.Equal(t, []Author{{"Jane Doe", "/u/j"}, {"John Smith", ""}}, a.Authors)
+	assert.Contains(t, a.Text, "Interview with Jane Doe about Go modules.")
+	assert.Contains(t, a.Text, "share")
+
+	s = pageWith("", `<p>By <a rel="author" href="/u/j">Jane Doe</a> and <a rel="author" href="/u/i">Иван Петров</a>, 12.05.2024</p>`)
+	a, err = ExtractFromHTML(s, nil, WithExtractor(CETD{}))
+	assert.NoError(t, err)
+	assert.Len(t, a.Authors, 2)
+	assert.NotContains(t, a.Text, "Jane Doe")
+}
+
+func TestExtractSiteByline(t *testing.T) {
+	rule, err := site.Parse("example.com.json", []byte(`{"author": [".byline a"], "date": [".byline time"]}`))
+	assert.NoError(t, err)
+	s := pageWith("", `<p class="byline">By <a href="/u/jane">Jane Doe</a>, `+
+		`<time datetime="2024-05-12T10:00:00Z">May 12</time></p>`)
+	base, _ := url.Parse("https://example.com/post")
+
+	a, err := ExtractFromHTML(s, base, WithExtractor(CETD{}), WithSite(rule))
+	assert.NoError(t, err)
+	assert.Equal(t, "site", a.DateSource)
+	assert.Equal(t, 1.0, a.DateConfidence)
+	assert.Equal(t, "Jane Doe", a.Byline)
+	assert.NotContains(t, a.Text, "By Jane Doe")
+}
@@ -0,0 +1,284 @@
+package web
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// dateLayouts are tried in order by ParseDate before localized formats
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+}
+
+// dateConfidence is confidence of publication date by source
+var dateConfidence = map[string]float64{
+	"site":      1,
+	"json-ld":   0.95,
+	"meta":      0.9,
+	"microdata": 0.85,
+	"time":      0.7,
+	"text":      0.5,
+	"url":       0.4,
+}
+
+// months are month numbers by lowercase english and russian names and abbreviations
+var months = map[string]time.Month{}
+
+func init() {
+	names := [][]string{
+		{"january", "jan", "январь", "января", "янв"},
+		{"february", "feb", "февраль", "февраля", "фев"},
+		{"march", "mar", "март", "марта", "мар"},
+		{"april", "apr", "апрель", "апреля", "апр"},
+		{"may", "май", "мая"},
+		{"june", "jun", "июнь", "июня", "июн"},
+		{"july", "jul", "июль", "июля", "июл"},
+		{"august", "aug", "август", "августа", "авг"},
+		{"september", "sep", "sept", "сентябрь", "сентября", "сен", "сент"},
+		{"october", "oct", "октябрь", "октября", "окт"},
+		{"november", "nov", "ноябрь", "ноября", "ноя", "нояб"},
+		{"december", "dec", "декабрь", "декабря", "дек"},
+	}
+	for i, ns := range names {
+		for _, n := range ns {
+			months[n] = time.Month(i + 1)
+		}
+	}
+}
+
+const monthRe = `([a-zA-Zа-яА-ЯёЁ]{3,9})\.?`
+const clockRe = `(?:,?\s*(?:в|at)?\s*(\d{1,2}):(\d{2})(?:\s*([aApP][mM]))?)?`
+
+var (
+	// 12 мая 2024, 12 May 2024 10:30
+	dayMonthRe = regexp.MustCompile(`\b(\d{1,2})\s+` + monthRe + `,?\s+(\d{4})(?:\s*(?:года|г\.?))?` + clockRe)
+	// May 12, 2024 10:30 pm
+	monthDayRe = regexp.MustCompile(monthRe + `\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{4})` + clockRe)
+	// 12.05.2024 10:30
+	numericRe = regexp.MustCompile(`\b(\d{1,2})\.(\d{1,2})\.(\d{4})` + clockRe)
+	// 2024-05-12
+	isoRe = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	// /2024/05/12/ or /2024-05-12 in url path, day may be missing
+	urlDateRe = regexp.MustCompile(`/((?:19|20)\d{2})[/-](\d{1,2})(?:[/-](\d{1,2}))?(?:[/-]|$)`)
+	// class or id of elements with byline and date
+	dateClassRe = regexp.MustCompile(`(?i)date|time|publish|posted|byline|entry-meta|post-meta|article-meta|meta-info`)
+	// labeled date in page text
+	dateLabelRe = regexp.MustCompile(`(?i)(?:published|posted|updated|опубликовано|дата публикации|дата)\s*(?:on|:)?\s*(.{6,40})`)
+)
+
+// ParseDate parse RFC 3339 and other machine formats, english and russian
+// month names, e.g. "12 мая 2024 г., 10:30" or "May 12, 2024", and dd.mm.yyyy.
+// Dates without zone are UTC
+func ParseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, l := range dateLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, true
+		}
+	}
+	return findDate(s)
+}
+
+// findDate return first localized date found in s
+func findDate(s string) (time.Time, bool) {
+	for _, m := range dayMonthRe.FindAllStringSubmatch(s, -1) {
+		if mon, ok := months[strings.ToLower(m[2])]; ok {
+			if t, ok := makeDate(m[3], mon, m[1], m[4:]); ok {
+				return t, true
+			}
+		}
+	}
+	for _, m := range monthDayRe.FindAllStringSubmatch(s, -1) {
+		if mon, ok := months[strings.ToLower(m[1])]; ok {
+			if t, ok := makeDate(m[3], mon, m[2], m[4:]); ok {
+				return t, true
+			}
+		}
+	}
+	if m := numericRe.FindStringSubmatch(s); m != nil {
+		mon, _ := strconv.Atoi(m[2])
+		return makeDate(m[3], time.Month(mon), m[1], m[4:])
+	}
+	if m := isoRe.FindStringSubmatch(s); m != nil {
+		mon, _ := strconv.Atoi(m[2])
+		return makeDate(m[1], time.Month(mon), m[3], nil)
+	}
+	return time.Time{}, false
+}
+
+// makeDate validate parts of date, clock is hour, minute and optional am/pm
+func makeDate(year string, mon time.Month, day string, clock []string) (time.Time, bool) {
+	y, _ := strconv.Atoi(year)
+	d, _ := strconv.Atoi(day)
+	if y < 1990 || y > time.Now().Year()+1 || mon < 1 || mon > 12 || d < 1 || d > 31 {
+		return time.Time{}, false
+	}
+	h, min := 0, 0
+	if len(clock) == 3 && clock[0] != "" {
+		h, _ = strconv.Atoi(clock[0])
+		min, _ = strconv.Atoi(clock[1])
+		switch strings.ToLower(clock[2]) {
+		case "pm":
+			if h < 12 {
+				h += 12
+			}
+		case "am":
+			if h == 12 {
+				h = 0
+			}
+		}
+		if h > 23 || min > 59 {
+			h, min = 0, 0
+		}
+	}
+	t := time.Date(y, mon, d, h, min, 0, 0, time.UTC)
+	if t.Day() != d {
+		// 31 of 30 days month
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// detectDate fill publication date if metadata has none from <time> elements,
+// byline text or url path, and set confidence of date source
+func (m *Metadata) detectDate(page *html.Node, pageURL *url.URL) {
+	if m.Published.IsZero() {
+		if t, ok := timeElementDate(page); ok {
+			m.Published, m.DateSource = t, "time"
+		} else if t, ok := textDate(page); ok {
+			m.Published, m.DateSource = t, "text"
+		} else if t, _, ok := urlDate(pageURL); ok {
+			m.Published, m.DateSource = t, "url"
+		}
+	}
+	if m.Published.IsZero() {
+		return
+	}
+	m.DateConfidence = dateConfidence[m.DateSource]
+	t, exact, ok := urlDate(pageURL)
+	switch {
+	case !ok:
+	case m.DateSource == "url" && !exact:
+		// month only
+		m.DateConfidence /= 2
+	case m.DateSource != "url" && exact && sameDay(t, m.Published):
+		// url agrees with page
+		m.DateConfidence += (1 - m.DateConfidence) / 2
+	}
+}
+
+// timeElementDate return date of <time datetime>, publication time is preferred
+func timeElementDate(page *html.Node) (time.Time, bool) {
+	var first time.Time
+	var found time.Time
+	var f func(*html.Node) bool
+	f = func(n *html.Node) bool {
+		if n.Type == html.ElementNode && n.Data == "time" {
+			v := attr(n, "datetime")
+			if v == "" {
+				v = nodeText(n)
+			}
+			if t, ok := ParseDate(v); ok {
+				prop := strings.ToLower(attr(n, "itemprop") + " " + attr(n, "class"))
+				if hasAttr(n, "pubdate") || strings.Contains(prop, "publish") {
+					found = t
+					return true
+				}
+				if first.IsZero() && !strings.Contains(prop, "modified") && !strings.Contains(prop, "updated") {
+					first = t
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if f(c) {
+				return true
+			}
+		}
+		return false
+	}
+	if f(page); !found.IsZero() {
+		return found, true
+	}
+	return first, !first.IsZero()
+}
+
+// textDate find date in text of byline like elements or after labels like "Опубликовано:"
+func textDate(page *html.Node) (time.Time, bool) {
+	body := findElement(page, "body")
+	if body == nil {
+		return time.Time{}, false
+	}
+	var found time.Time
+	var f func(*html.Node) bool
+	f = func(n *html.Node) bool {
+		if n.Type != html.ElementNode || n.Data == "script" || n.Data == "style" {
+			return false
+		}
+		if dateClassRe.MatchString(attr(n, "class") + " " + attr(n, "id")) {
+			txt := spaceRe.ReplaceAllString(nodeText(n), " ")
+			if utf8.RuneCountInString(txt) < 200 {
+				if t, ok := findDate(txt); ok {
+					found = t
+					return true
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if f(c) {
+				return true
+			}
+		}
+		return false
+	}
+	if f(body) {
+		return found, true
+	}
+	for _, m := range dateLabelRe.FindAllStringSubmatch(spaceRe.ReplaceAllString(nodeText(body), " "), -1) {
+		if t, ok := findDate(m[1]); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// urlDate return date from path segments like /2024/05/12/, first day of
+// month if day is missing, exact reports whether day was present
+func urlDate(u *url.URL) (t time.Time, exact bool, ok bool) {
+	if u == nil {
+		return t, false, false
+	}
+	m := urlDateRe.FindStringSubmatch(u.Path)
+	if m == nil {
+		return t, false, false
+	}
+	mon, _ := strconv.Atoi(m[2])
+	day := m[3]
+	if day == "" {
+		day = "1"
+	}
+	t, ok = makeDate(m[1], time.Month(mon), day, nil)
+	return t, m[3] != "", ok
+}
+
+// sameDay report whether date d is calendar day of t in t's own zone
+func sameDay(d, t time.Time) bool {
+	return d.Year() == t.Year() && d.YearDay() == t.YearDay()
+}
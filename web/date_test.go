@@ -0,0 +1,59 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestParseDate(t *testing.T) {
+	for s, want := range map[string]string{
+		"2024-05-12T08:30:00+03:00":     "2024-05-12T08:30:00+03:00",
+		"2024-05-12":                    "2024-05-12T00:00:00Z",
+		"12 мая 2024 г., 10:30":         "2024-05-12T10:30:00Z",
+		"Опубликовано 3 января 2023":    "2023-01-03T00:00:00Z",
+		"1 сент. 2022 в 09:05":          "2022-09-01T09:05:00Z",
+		"May 12th, 2024 at 10:30 pm":    "2024-05-12T22:30:00Z",
+		"Posted on 12 Dec 2021":         "2021-12-12T00:00:00Z",
+		"12.05.2024 10:30":              "2024-05-12T10:30:00Z",
+		"Tue, 14 May 2024 10:00:00 GMT": "2024-05-14T10:00:00Z",
+	} {
+		d, ok := ParseDate(s)
+		assert.True(t, ok, s)
+		assert.Equal(t, want, d.Format(time.RFC3339), s)
+	}
+	for _, s := range []string{"", "yesterday", "31 февраля 2024", "12 apples 2024"} {
+		_, ok := ParseDate(s)
+		assert.False(t, ok, s)
+	}
+}
+
+func TestDetectDate(t *testing.T) {
+	for _, c := range []struct {
+		page, url, date, source string
+		conf                    float64
+	}{
+		{`<meta property="article:published_time" content="2024-05-12T10:00:00Z">`, "/2024/05/12/post", "2024-05-12T10:00:00Z", "meta", 0.95},
+		{`<time datetime="2024-05-13T09:00:00Z" class="updated"></time><time pubdate datetime="2024-05-12T09:00:00Z">`, "/post", "2024-05-12T09:00:00Z", "time", 0.7},
+		{`<div class="post-meta">Автор: Иван, 12 мая 2024</div>`, "/post", "2024-05-12T00:00:00Z", "text", 0.5},
+		{`<p>Just text</p>`, "/news/2024/05/12/post", "2024-05-12T00:00:00Z", "url", 0.4},
+		{`<p>Just text</p>`, "/news/2024/05/post", "2024-05-01T00:00:00Z", "url", 0.2},
+		{`<p>Just text</p>`, "/post", "", "", 0},
+	} {
+		page, _ := html.Parse(strings.NewReader(c.page))
+		u, _ := url.Parse("https://example.com" + c.url)
+		m := ReadMetadata(page)
+		m.detectDate(page, u)
+		if c.date == "" {
+			assert.True(t, m.Published.IsZero(), c.page)
+			continue
+		}
+		assert.Equal(t, c.date, m.Published.Format(time.RFC3339), c.page)
+		assert.Equal(t, c.source, m.DateSource, c.page)
+		assert.InDelta(t, c.conf, m.DateConfidence, 0.001, c.page)
+	}
+}
@@ -0,0 +1,162 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/dyatlov/go-readability"
+	"github.com/recoilme/readability/cetd"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Extractor find main content of parsed page. Content is returned as html
+// document and confidence is in range from 0 to 1. Extractor must not modify page
+type Extractor interface {
+	Name() string
+	Extract(page *html.Node, baseURL *url.URL, o *Options) (content *html.Node, confidence float64, err error)
+}
+
+// Readability is extractor backed by github.com/dyatlov/go-readability
+type Readability struct{}
+
+// CETD is extractor backed by composite text density, see package cetd
+type CETD struct{}
+
+// Extractors are built-in extractors by name
+var Extractors = map[string]Extractor{
+	"readability": Readability{},
+	"cetd":        CETD{},
+	"ensemble":    Ensemble{Extractors: []Extractor{Readability{}, CETD{}}},
+}
+
+// Name return engine name
+func (Readability) Name() string { return "readability" }
+
+// Extract run go-readability configured by options
+func (Readability) Extract(page *html.Node, baseURL *url.URL, o *Options) (*html.Node, float64, error) {
+	doc, err := readability.NewDocument(renderNode(page))
+	if err != nil {
+		return nil, 0, err
+	}
+	o.apply(doc)
+	content, err := html.Parse(strings.NewReader(doc.Content()))
+	if err != nil {
+		return nil, 0, err
+	}
+	return content, confidence(content), nil
+}
+
+// Name return engine name
+func (CETD) Name() string { return "cetd" }
+
+// Extract run density extraction on page cleaned by options cleaner and
+// sanitize result by options whitelists
+func (CETD) Extract(page *html.Node, baseURL *url.URL, o *Options) (*html.Node, float64, error) {
+	n, err := cetd.Extract(page)
+	if err == cetd.ErrNoCandidate {
+		return nil, 0, ErrNoContent
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	content := wrapDocument(sanitize(n, o))
+	return content, confidence(content), nil
+}
+
+// confidence estimate quality of extracted content by amount of text and link density
+func confidence(content *html.Node) float64 {
+	text, links := 0, 0
+	var f func(*html.Node, bool)
+	f = func(n *html.Node, inLink bool) {
+		if n.Type == html.TextNode {
+			l := len([]rune(strings.TrimSpace(n.Data)))
+			text += l
+			if inLink {
+				links += l
+			}
+		}
+		inLink = inLink || (n.Type == html.ElementNode && n.Data == "a")
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c, inLink)
+		}
+	}
+	f(content, false)
+	if text == 0 {
+		return 0
+	}
+	size := float64(text) / 2000
+	if size > 1 {
+		size = 1
+	}
+	return size * (1 - float64(links)/float64(text))
+}
+
+// sanitize keep only whitelisted tags and attributes, other elements are
+// replaced by their children, dropTags are removed with content
+func sanitize(n *html.Node, o *Options) *html.Node {
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			if c.Type == html.ElementNode {
+				f(c)
+				switch {
+				case dropTags[c.Data]:
+					n.RemoveChild(c)
+				case !contains(o.WhitelistTags, c.Data):
+					for cc := c.FirstChild; cc != nil; {
+						nn := cc.NextSibling
+						c.RemoveChild(cc)
+						n.InsertBefore(cc, c)
+						cc = nn
+					}
+					n.RemoveChild(c)
+				default:
+					keep := c.Attr[:0]
+					for _, a := range c.Attr {
+						if contains(o.WhitelistAttrs[c.Data], a.Key) {
+							keep = append(keep, a)
+						}
+					}
+					c.Attr = keep
+				}
+			} else if c.Type != html.TextNode {
+				n.RemoveChild(c)
+			}
+			c = next
+		}
+	}
+	f(n)
+	n.Attr = nil
+	return n
+}
+
+// dropTags are removed with content by sanitize
+var dropTags = map[string]bool{"script": true, "style": true, "noscript": true, "iframe": true, "object": true, "embed": true}
+
+// wrapDocument put n into body of new html document
+func wrapDocument(n *html.Node) *html.Node {
+	doc := &html.Node{Type: html.DocumentNode}
+	root := &html.Node{Type: html.ElementNode, DataAtom: atom.Html, Data: "html"}
+	head := &html.Node{Type: html.ElementNode, DataAtom: atom.Head, Data: "head"}
+	body := &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"}
+	doc.AppendChild(root)
+	root.AppendChild(head)
+	root.AppendChild(body)
+	if n.Parent != nil {
+		n.Parent.RemoveChild(n)
+	}
+	body.AppendChild(n)
+	return doc
+}
+
+// cloneNode return deep copy of n
+func cloneNode(n *html.Node) *html.Node {
+	cp := &html.Node{Type: n.Type, DataAtom: n.DataAtom, Data: n.Data, Namespace: n.Namespace}
+	cp.Attr = append(cp.Attr, n.Attr...)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		cp.AppendChild(cloneNode(c))
+	}
+	return cp
+}
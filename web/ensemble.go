@@ -0,0 +1,159 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// Ensemble run several extractors on the same page and vote for the best content
+type Ensemble struct {
+	Extractors []Extractor
+}
+
+// Voter is extractor which reports winning engine and scores of all its
+// engines, extraction uses Vote instead of Extract for it
+type Voter interface {
+	Extractor
+	Vote(page *html.Node, baseURL *url.URL, o *Options) (*Vote, error)
+}
+
+// Vote is result of ensemble extraction
+type Vote struct {
+	// Engine is name of winning extractor
+	Engine string
+	// Confidence is winner's score from 0 to 1
+	Confidence float64
+	// Scores are scores of all extractors which returned content
+	Scores  map[string]float64
+	Content *html.Node
+}
+
+type candidate struct {
+	engine     string
+	content    *html.Node
+	confidence float64
+	words      map[string]bool
+	score      float64
+}
+
+// Name return engine name
+func (Ensemble) Name() string { return "ensemble" }
+
+// Extract return content of winning extractor
+func (e Ensemble) Extract(page *html.Node, baseURL *url.URL, o *Options) (*html.Node, float64, error) {
+	v, err := e.Vote(page, baseURL, o)
+	if err != nil {
+		return nil, 0, err
+	}
+	return v.Content, v.Confidence, nil
+}
+
+// Vote run extractors concurrently and score their candidates. Score is
+// extractor confidence (text length and link density) weighted by share of
+// candidate words found by other extractors. When candidates mostly overlap
+// the most complete one wins
+func (e Ensemble) Vote(page *html.Node, baseURL *url.URL, o *Options) (*Vote, error) {
+	cands := make([]*candidate, len(e.Extractors))
+	errs := make([]error, len(e.Extractors))
+	var wg sync.WaitGroup
+	for i, ex := range e.Extractors {
+		wg.Add(1)
+		go func(i int, ex Extractor) {
+			defer wg.Done()
+			content, conf, err := ex.Extract(page, baseURL, o)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			cands[i] = &candidate{engine: ex.Name(), content: content, confidence: conf, words: wordSet(content)}
+		}(i, ex)
+	}
+	wg.Wait()
+
+	var ok []*candidate
+	for _, c := range cands {
+		if c != nil && len(c.words) > 0 {
+			ok = append(ok, c)
+		}
+	}
+	if len(ok) == 0 {
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrNoContent
+	}
+
+	v := &Vote{Scores: make(map[string]float64, len(ok))}
+	var best *candidate
+	for _, c := range ok {
+		others := make(map[string]bool)
+		for _, o := range ok {
+			if o != c {
+				for w := range o.words {
+					others[w] = true
+				}
+			}
+		}
+		agreement := 1.0
+		if len(ok) > 1 {
+			agreement = overlap(c.words, others)
+		}
+		c.score = c.confidence * (0.5 + 0.5*agreement)
+		v.Scores[c.engine] = c.score
+		if best == nil || c.score > best.score {
+			best = c
+		}
+	}
+	for _, c := range ok {
+		if c != best && len(c.words) > len(best.words) && jaccard(c.words, best.words) > 0.8 {
+			best = c
+		}
+	}
+	v.Engine = best.engine
+	v.Confidence = best.score
+	v.Content = best.content
+	return v, nil
+}
+
+// wordSet return set of lowercased words of node text
+func wordSet(n *html.Node) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.Fields(strings.ToLower(RenderText(n))) {
+		words[w] = true
+	}
+	return words
+}
+
+// overlap return share of a words present in b
+func overlap(a, b map[string]bool) float64 {
+	if len(a) == 0 {
+		return 0
+	}
+	n := 0
+	for w := range a {
+		if b[w] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(a))
+}
+
+// jaccard return size of intersection divided by size of union
+func jaccard(a, b map[string]bool) float64 {
+	n := 0
+	for w := range a {
+		if b[w] {
+			n++
+		}
+	}
+	union := len(a) + len(b) - n
+	if union == 0 {
+		return 0
+	}
+	return float64(n) / float64(union)
+}
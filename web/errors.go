@@ -0,0 +1,55 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+var (
+	// ErrNoContent is returned when a page was fetched but no readable content was found
+	ErrNoContent = errors.New("no readable content")
+	// ErrTimeout is returned when fetch deadline exceeded
+	ErrTimeout = errors.New("timeout")
+	// ErrTooLarge is returned when response or document exceeds configured limits
+	ErrTooLarge = errors.New("too large")
+	// ErrNotHTML is returned when extraction is requested for non html response
+	ErrNotHTML = errors.New("not html")
+	// ErrRead is returned when response body can't be read
+	ErrRead = errors.New("read body")
+	// ErrTooManyRedirects is returned when redirect limit exceeded
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// HTTPStatusError is returned for non 2xx responses
+type HTTPStatusError struct {
+	StatusCode int
+	URL        string
+	Header     http.Header
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("%s: statusCode:%d", e.URL, e.StatusCode)
+}
+
+// isTimeout report whether err is caused by deadline
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// wrapErr mark timeouts with ErrTimeout and keep original error in chain
+func wrapErr(err error) error {
+	if err == nil || errors.Is(err, ErrTimeout) {
+		return err
+	}
+	if isTimeout(err) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+var spaceRe = regexp.MustCompile(`\s+`)
+
+// ExtractFromReader extract readable article from already fetched html.
+// Charset is detected from BOM and <meta> tags, baseURL may be nil
+func ExtractFromReader(r io.Reader, baseURL *url.URL, opts ...Option) (*Article, error) {
+	return extract(r, "", baseURL, newOptions(opts))
+}
+
+// ExtractFromBytes extract readable article from html bytes in any charset
+func ExtractFromBytes(b []byte, baseURL *url.URL, opts ...Option) (*Article, error) {
+	return extract(bytes.NewReader(b), "", baseURL, newOptions(opts))
+}
+
+// ExtractFromHTML extract readable article from utf8 html string
+func ExtractFromHTML(s string, baseURL *url.URL, opts ...Option) (*Article, error) {
+	return extractString(s, baseURL, newOptions(opts))
+}
+
+// extract decode r to utf8 using contentType hint and run pipeline
+func extract(r io.Reader, contentType string, baseURL *url.URL, o *Options) (*Article, error) {
+	utf8, err := charset.NewReader(r, contentType)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadAll(utf8)
+	if err != nil {
+		return nil, err
+	}
+	return extractString(string(b), baseURL, o)
+}
+
+// extractString run readability pipeline on utf8 html
+func extractString(s string, baseURL *url.URL, o *Options) (*Article, error) {
+	page, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return nil, err
+	}
+	if err = CheckDOM(page, o.MaxNodes, o.MaxDepth); err != nil {
+		return nil, err
+	}
+	FixLazyImages(page)
+	meta := ReadMetadata(page)
+	meta.detectDate(page, baseURL)
+	byline := meta.detectAuthors(page)
+	var body *html.Node
+	var conf float64
+	var name string
+	var scores map[string]float64
+	var sf *siteFields
+	if o.Site != nil {
+		o.Site.Clean(page)
+		sf = readSite(page, o.Site)
+	}
+	// byline is removed after site fields are read, their selectors may point into it
+	if byline != nil && byline.Parent != nil {
+		byline.Parent.RemoveChild(byline)
+	}
+	if o.Site != nil {
+		if body = siteContent(page, o); body != nil {
+			name, conf = "site", 1
+		}
+	}
+	if body == nil {
+		if o.Cleaner != nil {
+			o.Cleaner.Clean(page)
+		}
+		e := o.Extractor
+		if e == nil {
+			e = Readability{}
+		}
+		name = e.Name()
+		if vr, ok := e.(Voter); ok {
+			v, err := vr.Vote(page, baseURL, o)
+			if err != nil {
+				return nil, err
+			}
+			body, conf, name, scores = v.Content, v.Confidence, v.Engine, v.Scores
+		} else if body, conf, err = e.Extract(page, baseURL, o); err != nil {
+			return nil, err
+		}
+	}
+	normalizeSpace(body)
+	ResolveURLs(body, documentBase(page, baseURL))
+
+	u := ""
+	if baseURL != nil {
+		u = baseURL.String()
+	}
+	a := newArticle(u, u, meta, body)
+	a.Engine = name
+	a.Confidence = conf
+	a.EngineScores = scores
+	if sf != nil {
+		sf.apply(a)
+	}
+	if a.WordCount == 0 {
+		return a, ErrNoContent
+	}
+	return a, nil
+}
@@ -0,0 +1,212 @@
+package web
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/recoilme/readability/clean"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const para = "Это длинный абзац статьи, который должен попасть в извлечённый текст. " +
+	"Он повторяется несколько раз, чтобы алгоритм счёл его основным содержимым страницы. "
+
+func page(charsetMeta string) string {
+	return pageWith(charsetMeta, "")
+}
+
+// pageWith return article page with head markup and lead markup put before
+// article paragraphs
+func pageWith(head, lead string) string {
+	return `<html lang="ru"><head>` + head + `<title>Заголовок</title></head><body>
+<div class="menu" id="menu"><a href="/">Главная</a> <a href="/news">Новости</a> <a href="/sport">Спорт</a>
+<a href="/culture">Культура</a> <a href="/tech">Технологии</a> <a href="/about">О проекте</a></div>
+<div class="article" id="article">` + lead + `<p>` + strings.Repeat(para, 4) + `</p><p>` + strings.Repeat(para, 4) + `</p></div>
+</body></html>`
+}
+
+func TestExtractFromBytesCharset(t *testing.T) {
+	src, err := charmap.Windows1251.NewEncoder().String(page(`<meta charset="windows-1251">`))
+	assert.NoError(t, err)
+	base, _ := url.Parse("https://example.com/a")
+
+	a, err := ExtractFromBytes([]byte(src), base)
+	assert.NoError(t, err)
+	assert.Equal(t, "Заголовок", a.Title)
+	assert.Equal(t, "ru", a.Language)
+	assert.Contains(t, a.Text, "длинный абзац статьи")
+	assert.Equal(t, "https://example.com/a", a.FinalURL)
+}
+
+func TestResolveURLs(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><head><base href="/news/"></head><body>
+<a href="../about">a</a><a href="#top">top</a><img src="img/a.png" srcset="a.png 1x, //cdn.example.com/a@2x.png 2x">
+<img srcset="b.jpg 1x,b@2x.jpg 2x"><img srcset="c,1.jpg,, c,2.jpg 2x">
+<video poster="p.jpg"></video><img src="data:image/gif;base64,R0lGOD"></body></html>`))
+	assert.NoError(t, err)
+	page, _ := url.Parse("https://example.com/2024/05/page.html")
+	ResolveURLs(doc, documentBase(doc, page))
+	out := renderNode(doc)
+
+	assert.Contains(t, out, `href="https://example.com/about"`)
+	assert.Contains(t, out, `href="#top"`)
+	assert.Contains(t, out, `src="https://example.com/news/img/a.png"`)
+	assert.Contains(t, out, `srcset="https://example.com/news/a.png 1x, https://cdn.example.com/a@2x.png 2x"`)
+	assert.Contains(t, out, `srcset="https://example.com/news/b.jpg 1x, https://example.com/news/b@2x.jpg 2x"`)
+	assert.Contains(t, out, `srcset="https://example.com/news/c,1.jpg, https://example.com/news/c,2.jpg 2x"`)
+	assert.Contains(t, out, `poster="https://example.com/news/p.jpg"`)
+	assert.Contains(t, out, `src="data:image/gif;base64,R0lGOD"`)
+}
+
+func TestFixLazyImages(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+<img id="a" src="/img/placeholder.gif" data-src="/real/a.jpg">
+<img id="b" src="data:image/gif;base64,R0lGOD" data-srcset="/b-320.jpg 320w, /b-1024.jpg 1024w">
+<img id="c" src="/c.jpg" data-src="/c-big.jpg">
+<img class="lazy" src="/i/blank.gif"><noscript><img id="d" src="/real/d.jpg"></noscript>
+</body></html>`))
+	assert.NoError(t, err)
+	FixLazyImages(doc)
+	out := renderNode(doc)
+
+	assert.Contains(t, out, `<img id="a" src="/real/a.jpg"`)
+	assert.Contains(t, out, `<img id="b" src="/b-1024.jpg"`)
+	assert.Contains(t, out, `<img id="c" src="/c.jpg"`)
+	assert.Contains(t, out, `<img id="d" src="/real/d.jpg"/>`)
+	assert.NotContains(t, out, "noscript")
+	assert.NotContains(t, out, "blank.gif")
+}
+
+func TestExtractCETD(t *testing.T) {
+	f, err := os.Open("../CECTD-DS/html/4.htm")
+	assert.NoError(t, err)
+	defer f.Close()
+	base, _ := url.Parse("https://habr.com/post/")
+
+	a, err := ExtractFromReader(f, base, WithExtractor(CETD{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "cetd", a.Engine)
+	assert.Contains(t, a.Text, "Что это вообще такое")
+	assert.NotContains(t, a.Content, "class=")
+	assert.True(t, a.Confidence > 0.5)
+}
+
+func TestExtractEnsemble(t *testing.T) {
+	b, err := os.ReadFile("../CECTD-DS/html/4.htm")
+	assert.NoError(t, err)
+	base, _ := url.Parse("https://habr.com/post/")
+
+	a, err := ExtractFromBytes(b, base, WithExtractor(Extractors["ensemble"]))
+	assert.NoError(t, err)
+	assert.Contains(t, []string{"readability", "cetd"}, a.Engine)
+	assert.Equal(t, a.EngineScores[a.Engine], a.Confidence)
+	assert.Contains(t, a.Text, "Что это вообще такое")
+}
+
+// byID extractor return element with id equal to its name
+type byID string
+
+func (e byID) Name() string { return string(e) }
+
+func (e byID) Extract(page *html.Node, baseURL *url.URL, o *Options) (*html.Node, float64, error) {
+	n := findNode(page, func(n *html.Node) bool { return attr(n, "id") == string(e) })
+	if n == nil {
+		return nil, 0, ErrNoContent
+	}
+	content := wrapDocument(cloneNode(n))
+	return content, confidence(content), nil
+}
+
+func TestExtractEnsembleSidebar(t *testing.T) {
+	for _, e := range []Extractor{
+		Ensemble{Extractors: []Extractor{byID("menu"), byID("article")}},
+		&Ensemble{Extractors: []Extractor{byID("article"), byID("menu")}},
+	} {
+		a, err := ExtractFromHTML(page(""), nil, WithExtractor(e))
+		assert.NoError(t, err)
+		assert.Equal(t, "article", a.Engine)
+		assert.Len(t, a.EngineScores, 2)
+		assert.Equal(t, 0.0, a.EngineScores["menu"], "link text only")
+		assert.True(t, a.EngineScores["article"] > 0.3, a.EngineScores)
+		assert.Equal(t, a.EngineScores["article"], a.Confidence)
+		assert.NotContains(t, a.Text, "Главная")
+	}
+}
+
+func TestExtractMetadata(t *testing.T) {
+	s := pageWith(`<meta property="og:title" content="OG title"><meta property="og:site_name" content="Site">
+<meta property="og:image" content="/img/og.jpg"><meta name="twitter:image" content="https://cdn.example.com/tw.jpg">
+<meta name="description" content="Meta description"><meta name="keywords" content="go, html">
+<meta property="article:modified_time" content="2024-05-13T10:00:00Z">
+<script type="application/ld+json">{"@context": "https://schema.org", "@graph": [
+ {"@type": "WebSite", "name": "Not article"},
+ {"@type": "NewsArticle", "headline": "JSON-LD headline", "datePublished": "2024-05-12T08:30:00+03:00",
+  "author": [{"@type": "Person", "name": "Иван Петров"}, {"@type": "Person", "name": "Jane Doe"}],
+  "keywords": ["news", "go"]}]}</script>`,
+		`<div itemscope itemtype="https://schema.org/Article"><span itemprop="description">Microdata description</span></div>`)
+	base, _ := url.Parse("https://example.com/news/1")
+
+	a, err := ExtractFromHTML(s, base, WithExtractor(CETD{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "JSON-LD headline", a.Title)
+	assert.Equal(t, "Иван Петров, Jane Doe", a.Byline)
+	assert.Equal(t, "Site", a.SiteName)
+	assert.Equal(t, "Microdata description", a.Description)
+	assert.Equal(t, "ru", a.Language)
+	assert.Equal(t, "2024-05-12T05:30:00Z", a.PublishedTime.UTC().Format(time.RFC3339))
+	assert.Equal(t, "2024-05-13T10:00:00Z", a.ModifiedTime.UTC().Format(time.RFC3339))
+	assert.Equal(t, []string{"https://example.com/img/og.jpg", "https://cdn.example.com/tw.jpg"}, a.Images)
+	assert.Equal(t, "https://example.com/img/og.jpg", a.LeadImage)
+	assert.Equal(t, []string{"news", "go", "html"}, a.Tags)
+}
+
+func TestReadMetadataCanonical(t *testing.T) {
+	link := `<link rel="canonical" href="https://example.com/link">`
+	og := `<meta property="og:url" content="https://example.com/og">`
+	ld := `<script type="application/ld+json">{"@type": "NewsArticle", "mainEntityOfPage": "https://example.com/ld"}</script>`
+	for head, want := range map[string]string{
+		og + ld + link: "https://example.com/link",
+		ld + og:        "https://example.com/og",
+		ld:             "https://example.com/ld",
+		"":             "",
+	} {
+		doc, err := html.Parse(strings.NewReader("<html><head>" + head + "</head><body></body></html>"))
+		assert.NoError(t, err)
+		assert.Equal(t, want, ReadMetadata(doc).Canonical, head)
+	}
+}
+
+func TestExtractForm(t *testing.T) {
+	s := strings.Replace(strings.Replace(page(""), "<body>", `<body><form id="aspnetForm" method="post">`, 1),
+		"</body>", "</form></body>", 1)
+	for _, e := range []string{"cetd", "ensemble"} {
+		a, err := ExtractFromHTML(s, nil, WithExtractor(Extractors[e]))
+		assert.NoError(t, err, e)
+		if assert.NotNil(t, a, e) {
+			assert.Contains(t, a.Text, "Это длинный абзац", e)
+		}
+	}
+}
+
+func TestExtractCleaner(t *testing.T) {
+	s := strings.Replace(strings.Replace(page(""), `<div class="article" id="article">`, `<aside class="article">`, 1),
+		"</p></div>", "</p></aside>", 1)
+	_, err := ExtractFromHTML(s, nil, WithExtractor(CETD{}))
+	assert.ErrorIs(t, err, ErrNoContent, "aside is removed by default")
+
+	a, err := ExtractFromHTML(s, nil, WithExtractor(CETD{}), WithCleaner(nil))
+	assert.NoError(t, err)
+	if assert.NotNil(t, a) {
+		assert.Contains(t, a.Text, "Это длинный абзац")
+	}
+
+	c, err := clean.New(clean.Rules{Selectors: []string{".article"}})
+	assert.NoError(t, err)
+	a, err = ExtractFromHTML(page(""), nil, WithExtractor(CETD{}), WithCleaner(c))
+	assert.ErrorIs(t, err, ErrNoContent, "only menu links are left")
+}
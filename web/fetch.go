@@ -0,0 +1,348 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/recoilme/readability/site"
+	"golang.org/x/net/html/charset"
+)
+
+// Fetcher download pages with caller supplied http client and headers
+type Fetcher struct {
+	// Client is used for all requests, http.DefaultClient if nil
+	Client *http.Client
+	// Headers are sent with every request, profile headers override them
+	Headers map[string]string
+	// Profiles are tried in order while server responds 403, 429 or 451
+	Profiles []Profile
+	// HostProfiles replace Profiles for host and its subdomains
+	HostProfiles map[string][]Profile
+	// AcceptLanguage overrides Accept-Language header if not empty
+	AcceptLanguage string
+	// Retry enables retries of transient failures if not nil
+	Retry *RetryPolicy
+	// MaxBodySize limits size of response body in bytes, 0 means no limit
+	MaxBodySize int64
+	// Compression makes fetcher request gzip/deflate and decode it itself
+	Compression bool
+	// MaxDecompressedSize limits size of decoded body if Compression is enabled
+	MaxDecompressedSize int64
+	// Guard if not nil checks every url and redirect, and for clients without
+	// own Transport every dialed address
+	Guard *Guard
+	// MaxRedirects limits number of followed redirects, 10 if zero, negative disables redirects
+	MaxRedirects int
+	// Timeout limits every fetch, parent context deadline is respected too
+	Timeout time.Duration
+	// Sites are site rules consulted before generic extraction, their headers override others
+	Sites *site.Rules
+}
+
+const defMaxRedirects = 10
+
+var schemeRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://`)
+
+// normURL prepend http:// to url without scheme, e.g. example.com/x
+func normURL(geturl string) string {
+	if !schemeRe.MatchString(geturl) {
+		return "http://" + geturl
+	}
+	return geturl
+}
+
+// DefaultFetcher is used by Get and GetContent
+var DefaultFetcher = NewFetcher(nil)
+
+// NewFetcher return fetcher with default headers and timeout
+func NewFetcher(client *http.Client) *Fetcher {
+	headers := make(map[string]string, len(defHeaders))
+	for k, v := range defHeaders {
+		headers[k] = v
+	}
+	profiles := make([]Profile, len(defProfiles))
+	copy(profiles, defProfiles)
+	return &Fetcher{
+		Client:              client,
+		Headers:             headers,
+		Profiles:            profiles,
+		Timeout:             defTimeOut,
+		MaxBodySize:         defMaxBodySize,
+		MaxDecompressedSize: defMaxDecompressedSize,
+	}
+}
+
+func (f *Fetcher) client() *http.Client {
+	c := f.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	wrapped := *c
+	if f.Guard != nil && wrapped.Transport == nil {
+		wrapped.Transport = f.Guard.Transport()
+	}
+	max := f.MaxRedirects
+	if max == 0 {
+		max = defMaxRedirects
+	}
+	checkRedirect := c.CheckRedirect
+	wrapped.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if max < 0 {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > max {
+			return fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, max)
+		}
+		if f.Guard != nil {
+			if err := f.Guard.CheckURL(req.URL); err != nil {
+				return err
+			}
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		return nil
+	}
+	return &wrapped
+}
+
+// request build GET request with fetcher and profile headers, geturl must have scheme
+func (f *Fetcher) request(ctx context.Context, geturl string, p Profile) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, geturl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.Guard != nil {
+		if err = f.Guard.CheckURL(req.URL); err != nil {
+			return nil, err
+		}
+	}
+	//Host
+	if len(req.URL.Host) > 2 {
+		req.Header.Set("Host", req.URL.Host)
+	}
+	for k, v := range f.Headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range p.Headers {
+		req.Header.Set(k, v)
+	}
+	if f.AcceptLanguage != "" {
+		req.Header.Set("Accept-Language", f.AcceptLanguage)
+	}
+	if f.Compression {
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
+	if rule := f.Sites.Lookup(req.URL.Hostname()); rule != nil {
+		for k, v := range rule.Headers {
+			req.Header.Set(k, v)
+		}
+	}
+	return req, nil
+}
+
+func (f *Fetcher) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if f.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, f.Timeout)
+}
+
+// Response is a fetched page
+type Response struct {
+	// URL is requested url
+	URL string
+	// FinalURL is url after redirects
+	FinalURL   string
+	StatusCode int
+	Header     http.Header
+	// ContentType is value of Content-Type header
+	ContentType string
+	// Body is raw response body
+	Body []byte
+	// Charset is detected charset of body
+	Charset string
+	// Text is body decoded to utf8
+	Text string
+	// Profile is name of header profile which got the response
+	Profile string
+	// Attempts is number of attempts made by retry policy
+	Attempts int
+	// Redirects are urls redirected from in order, empty if there were no redirects
+	Redirects []string
+}
+
+// Fetch download url and decode its body to utf8.
+// Response is returned with error for non 2xx status codes
+func (f *Fetcher) Fetch(ctx context.Context, geturl string) (*Response, error) {
+	return f.fetch(ctx, geturl, "")
+}
+
+// fetch retry fetchProfiles according to retry policy
+func (f *Fetcher) fetch(ctx context.Context, geturl, ua string) (*Response, error) {
+	// same url is used by profiles lookup and request
+	geturl = normURL(geturl)
+	if f.Retry == nil {
+		r, err := f.fetchProfiles(ctx, geturl, ua)
+		if r != nil {
+			r.Attempts = 1
+		}
+		return r, err
+	}
+	r, attempts, err := f.Retry.retry(ctx, func(ctx context.Context) (*Response, error) {
+		return f.fetchProfiles(ctx, geturl, ua)
+	})
+	if r != nil {
+		r.Attempts = attempts
+	}
+	return r, err
+}
+
+// fetchProfiles try profiles in order until response isn't blocked, ua replaces profiles if not empty
+func (f *Fetcher) fetchProfiles(ctx context.Context, geturl, ua string) (r *Response, err error) {
+	for _, p := range f.profiles(geturl, ua) {
+		r, err = f.fetchProfile(ctx, geturl, p)
+		var se *HTTPStatusError
+		if !errors.As(err, &se) || !fallbackStatus[se.StatusCode] {
+			break
+		}
+	}
+	return r, err
+}
+
+func (f *Fetcher) fetchProfile(ctx context.Context, geturl string, p Profile) (*Response, error) {
+	ctx, cncl := f.withTimeout(ctx)
+	defer cncl()
+	req, err := f.request(ctx, geturl, p)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	defer resp.Body.Close()
+	r := &Response{
+		URL:         geturl,
+		FinalURL:    resp.Request.URL.String(),
+		StatusCode:  resp.StatusCode,
+		Header:      resp.Header,
+		ContentType: resp.Header.Get("Content-Type"),
+		Profile:     p.Name,
+		Redirects:   redirects(resp),
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return r, &HTTPStatusError{StatusCode: resp.StatusCode, URL: r.FinalURL, Header: resp.Header}
+	}
+	r.Body, err = f.readBody(resp)
+	if errors.Is(err, ErrTooLarge) {
+		return r, err
+	}
+	if err != nil {
+		return r, wrapErr(fmt.Errorf("%w: %w", ErrRead, err))
+	}
+	err = r.decode()
+	return r, err
+}
+
+// redirects return chain of urls which led to resp
+func redirects(resp *http.Response) []string {
+	var chain []string
+	for req := resp.Request; req.Response != nil; req = req.Response.Request {
+		chain = append([]string{req.Response.Request.URL.String()}, chain...)
+	}
+	return chain
+}
+
+// IsHTML report whether response is html page by Content-Type or by sniffing body
+func (r *Response) IsHTML() bool {
+	ct := r.ContentType
+	if ct == "" {
+		ct = http.DetectContentType(r.Body)
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	switch mt {
+	case "text/html", "application/xhtml+xml":
+		return true
+	case "text/plain", "application/octet-stream":
+		// misconfigured servers, trust sniffing
+		return strings.HasPrefix(http.DetectContentType(r.Body), "text/html")
+	}
+	return false
+}
+
+// decode detect charset from BOM, Content-Type and <meta> and convert body to utf8
+func (r *Response) decode() error {
+	enc, name, _ := charset.DetermineEncoding(r.Body, r.ContentType)
+	r.Charset = name
+	b, err := enc.NewDecoder().Bytes(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Text = string(b)
+	return nil
+}
+
+// GetContent fetch url and extract readable article from it, site rule of
+// page host is used if there is one. It returns ErrNoContent if page was
+// fetched but has no readable text
+func (f *Fetcher) GetContent(ctx context.Context, u string, opts ...Option) (*Article, error) {
+	resp, err := f.Fetch(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsHTML() {
+		return nil, fmt.Errorf("%w: %s", ErrNotHTML, resp.ContentType)
+	}
+	base, err := url.Parse(resp.FinalURL)
+	if err != nil {
+		return nil, err
+	}
+	if rule := f.Sites.Lookup(base.Hostname()); rule != nil {
+		opts = append([]Option{WithSite(rule)}, opts...)
+	}
+	o := newOptions(opts)
+	a, err := extractString(resp.Text, base, o)
+	if a != nil {
+		a.URL = u
+		a.Profile = resp.Profile
+		a.Attempts = resp.Attempts
+		a.Redirects = resp.Redirects
+	}
+	if err == nil && o.Site != nil {
+		f.nextPages(ctx, a, o)
+	}
+	return a, err
+}
+
+// nextPages append content of following pages of multi page article,
+// it stops on first failed page
+func (f *Fetcher) nextPages(ctx context.Context, a *Article, o *Options) {
+	seen := map[string]bool{a.URL: true, a.FinalURL: true}
+	for i := 1; i < o.Site.Pages() && a.next != "" && !seen[a.next]; i++ {
+		seen[a.next] = true
+		resp, err := f.Fetch(ctx, a.next)
+		if err != nil || !resp.IsHTML() {
+			return
+		}
+		base, err := url.Parse(resp.FinalURL)
+		if err != nil {
+			return
+		}
+		p, err := extractString(resp.Text, base, o)
+		if err != nil {
+			return
+		}
+		a.appendPage(p)
+	}
+}
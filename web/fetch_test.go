@@ -0,0 +1,277 @@
+package web
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/recoilme/readability/site"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchProfileFallback(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.UserAgent(), "iPhone") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		assert.Equal(t, "en-US", r.Header.Get("Accept-Language"))
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	f := NewFetcher(ts.Client())
+	f.AcceptLanguage = "en-US"
+	resp, err := f.Fetch(context.Background(), ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "iphone", resp.Profile)
+	assert.Equal(t, "ok", resp.Text)
+
+	f.Profiles = []Profile{YandexBot}
+	_, err = f.Fetch(context.Background(), ts.URL)
+	var se *HTTPStatusError
+	assert.ErrorAs(t, err, &se)
+	assert.Equal(t, http.StatusForbidden, se.StatusCode)
+}
+
+func TestFetchHostProfilesNoScheme(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.UserAgent()))
+	}))
+	defer ts.Close()
+
+	f := NewFetcher(ts.Client())
+	f.HostProfiles = map[string][]Profile{"127.0.0.1": {YandexBot}}
+	resp, err := f.Fetch(context.Background(), strings.TrimPrefix(ts.URL, "http://")+"/x")
+	assert.NoError(t, err)
+	assert.Equal(t, "yandexbot", resp.Profile)
+	assert.Contains(t, resp.Text, "YandexBot")
+}
+
+func TestFetchRetry(t *testing.T) {
+	fails := 2
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= fails {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	f := NewFetcher(ts.Client())
+	_, err := f.Fetch(context.Background(), ts.URL)
+	assert.Error(t, err, "retries are opt-in")
+
+	calls = 0
+	f.Retry = DefaultRetryPolicy()
+	f.Retry.MinBackoff = time.Millisecond
+	resp, err := f.Fetch(context.Background(), ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, resp.Attempts)
+	assert.Equal(t, "ok", resp.Text)
+
+	calls = 0
+	f.Retry.MaxAttempts = 2
+	resp, err = f.Fetch(context.Background(), ts.URL)
+	var se *HTTPStatusError
+	assert.ErrorAs(t, err, &se)
+	assert.Equal(t, http.StatusServiceUnavailable, se.StatusCode)
+	assert.Equal(t, 2, resp.Attempts)
+
+	calls = 0
+	ctx, cncl := context.WithCancel(context.Background())
+	cncl()
+	_, err = f.Fetch(ctx, ts.URL)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, calls)
+}
+
+func TestFetchRetryTimeout(t *testing.T) {
+	// first handler still sleeps while second one runs
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	f := NewFetcher(ts.Client())
+	f.Timeout = 50 * time.Millisecond
+	f.Retry = DefaultRetryPolicy()
+	f.Retry.MinBackoff = time.Millisecond
+	resp, err := f.Fetch(context.Background(), ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resp.Attempts)
+}
+
+func TestRetryable(t *testing.T) {
+	p := DefaultRetryPolicy()
+	ctx := context.Background()
+	for err, want := range map[error]bool{
+		&HTTPStatusError{StatusCode: http.StatusBadGateway}:         true,
+		&HTTPStatusError{StatusCode: http.StatusNotFound}:           false,
+		fmt.Errorf("%w: %w", ErrTimeout, context.DeadlineExceeded):  true,
+		&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}:         true,
+		&net.OpError{Op: "dial", Err: ErrBlocked}:                   false,
+		&net.DNSError{Err: "no such host", IsNotFound: true}:        false,
+		&net.DNSError{Err: "server misbehaving", IsTemporary: true}: true,
+		fmt.Errorf("%w: %w", ErrRead, io.ErrUnexpectedEOF):          true,
+		&url.Error{Op: "Get", Err: ErrTooManyRedirects}:             false,
+		fmt.Errorf("%w: unsupported Content-Encoding: br", ErrRead): false,
+		ErrTooLarge: false,
+	} {
+		assert.Equal(t, want, p.retryable(ctx, err), err.Error())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	p := DefaultRetryPolicy()
+	p.Jitter = 0
+	resp := &Response{Header: http.Header{"Retry-After": {"3"}}}
+	assert.Equal(t, 3*time.Second, p.backoff(1, resp))
+	assert.Equal(t, p.MinBackoff*2, p.backoff(2, &Response{Header: http.Header{}}))
+	resp.Header.Set("Retry-After", "3600")
+	assert.Equal(t, p.MaxBackoff, p.backoff(1, resp))
+}
+
+func TestFetchTooLarge(t *testing.T) {
+	big := strings.Repeat("a", 1<<16)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") == "gzip, deflate" {
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(w)
+			zw.Write([]byte(big))
+			zw.Close()
+			return
+		}
+		w.Write([]byte(big))
+	}))
+	defer ts.Close()
+
+	f := NewFetcher(ts.Client())
+	f.MaxBodySize = 1 << 10
+	_, err := f.Fetch(context.Background(), ts.URL)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	f.Compression = true
+	f.MaxDecompressedSize = 1 << 12
+	_, err = f.Fetch(context.Background(), ts.URL)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	f.MaxDecompressedSize = 1 << 20
+	resp, err := f.Fetch(context.Background(), ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, big, resp.Text)
+}
+
+func TestFetchGuard(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	f := NewFetcher(nil)
+	f.Guard = NewGuard()
+	_, err := f.Fetch(context.Background(), ts.URL)
+	assert.ErrorIs(t, err, ErrBlocked)
+	_, err = f.Fetch(context.Background(), "file:///etc/passwd")
+	assert.ErrorIs(t, err, ErrBlocked)
+
+	f.Guard = NewGuard()
+	f.Guard.AllowPrivate = true
+	_, metadata, _ := net.ParseCIDR("169.254.0.0/16")
+	f.Guard.BlockedNets = append(f.Guard.BlockedNets, metadata)
+	_, err = f.Fetch(context.Background(), ts.URL)
+	assert.NoError(t, err)
+	_, err = f.Fetch(context.Background(), ts.URL+"/redirect")
+	assert.ErrorIs(t, err, ErrBlocked)
+
+	f.Guard.DenyHosts = []string{"127.0.0.1"}
+	_, err = f.Fetch(context.Background(), ts.URL)
+	assert.ErrorIs(t, err, ErrBlocked)
+}
+
+func TestFetchRedirects(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusMovedPermanently)
+		case "/b":
+			http.Redirect(w, r, "/c", http.StatusFound)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer ts.Close()
+
+	f := NewFetcher(ts.Client())
+	resp, err := f.Fetch(context.Background(), ts.URL+"/a")
+	assert.NoError(t, err)
+	assert.Equal(t, ts.URL+"/c", resp.FinalURL)
+	assert.Equal(t, []string{ts.URL + "/a", ts.URL + "/b"}, resp.Redirects)
+
+	f.MaxRedirects = 1
+	_, err = f.Fetch(context.Background(), ts.URL+"/a")
+	assert.ErrorIs(t, err, ErrTooManyRedirects)
+
+	f.MaxRedirects = -1
+	_, err = f.Fetch(context.Background(), ts.URL+"/a")
+	var se *HTTPStatusError
+	assert.ErrorAs(t, err, &se)
+	assert.Equal(t, http.StatusMovedPermanently, se.StatusCode)
+}
+
+func TestGetContentSite(t *testing.T) {
+	para := strings.Repeat("Site rules pick the right block of this page. ", 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "consent=1", r.Header.Get("Cookie"))
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch r.URL.Path {
+		case "/a":
+			w.Write([]byte(`<html><head><title>Page</title></head><body><h1 class="t">Real title</h1>` +
+				`<form><div class="story"><p>First ` + para + `</p></div></form>` +
+				`<nav><a class="next" href="/a/2">next</a></nav></body></html>`))
+		case "/a/2":
+			w.Write([]byte(`<html><body><div class="story"><p>Second ` + para + `</p></div>` +
+				`<a class="next" href="/a">first</a></body></html>`))
+		}
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "local.json"), []byte(`{"hosts": ["127.0.0.1"],
+		"content": [".story"], "title": ["h1.t"], "next_page": ["a.next"], "headers": {"Cookie": "consent=1"}}`), 0644))
+	f := NewFetcher(ts.Client())
+	var err error
+	f.Sites, err = site.Load(dir)
+	assert.NoError(t, err)
+	a, err := f.GetContent(context.Background(), ts.URL+"/a")
+	assert.NoError(t, err)
+	assert.Equal(t, "site", a.Engine)
+	assert.Equal(t, "Real title", a.Title)
+	assert.Contains(t, a.Text, "First Site rules")
+	assert.Contains(t, a.Text, "Second Site rules")
+	assert.Equal(t, 1, strings.Count(a.Text, "Second"))
+}
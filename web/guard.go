@@ -0,0 +1,133 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrBlocked is returned when url or address is forbidden by Guard
+var ErrBlocked = errors.New("blocked")
+
+// defBlockedNets are ranges not covered by net.IP helpers
+var defBlockedNets = []string{
+	"0.0.0.0/8",     // "this" network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved
+}
+
+// Guard restrict urls and addresses a fetcher may connect to.
+// Addresses are checked after dns resolution on every dial, so redirects
+// and dns rebinding can't reach blocked networks
+type Guard struct {
+	// Schemes are allowed url schemes, http and https if empty
+	Schemes []string
+	// AllowHosts if not empty allow only these hosts and their subdomains
+	AllowHosts []string
+	// DenyHosts block these hosts and their subdomains
+	DenyHosts []string
+	// AllowPrivate allow loopback, private and link-local addresses
+	AllowPrivate bool
+	// BlockedNets are additionally blocked networks
+	BlockedNets []*net.IPNet
+
+	once      sync.Once
+	transport *http.Transport
+}
+
+// NewGuard return guard blocking private networks for http and https urls
+func NewGuard() *Guard {
+	g := &Guard{Schemes: []string{"http", "https"}}
+	for _, cidr := range defBlockedNets {
+		_, n, _ := net.ParseCIDR(cidr)
+		g.BlockedNets = append(g.BlockedNets, n)
+	}
+	return g
+}
+
+// CheckURL check scheme and host of u
+func (g *Guard) CheckURL(u *url.URL) error {
+	schemes := g.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"http", "https"}
+	}
+	if !contains(schemes, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("%w: scheme %q", ErrBlocked, u.Scheme)
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("%w: empty host", ErrBlocked)
+	}
+	if matchHost(host, g.DenyHosts) {
+		return fmt.Errorf("%w: host %s", ErrBlocked, host)
+	}
+	if len(g.AllowHosts) > 0 && !matchHost(host, g.AllowHosts) {
+		return fmt.Errorf("%w: host %s not allowed", ErrBlocked, host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return g.CheckIP(ip)
+	}
+	return nil
+}
+
+// CheckIP check ip against private and blocked networks
+func (g *Guard) CheckIP(ip net.IP) error {
+	if !g.AllowPrivate && (ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast()) {
+		return fmt.Errorf("%w: address %s", ErrBlocked, ip)
+	}
+	for _, n := range g.BlockedNets {
+		if n.Contains(ip) {
+			return fmt.Errorf("%w: address %s in %s", ErrBlocked, ip, n)
+		}
+	}
+	return nil
+}
+
+// Control check resolved address before connect, it's used as net.Dialer.Control
+func (g *Guard) Control(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBlocked, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: address %s", ErrBlocked, host)
+	}
+	return g.CheckIP(ip)
+}
+
+// Transport return shared transport which dials only allowed addresses.
+// Proxy is disabled because proxy address would be checked instead of target
+func (g *Guard) Transport() *http.Transport {
+	g.once.Do(func() {
+		d := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: g.Control}
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.Proxy = nil
+		t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return d.DialContext(ctx, network, addr)
+		}
+		g.transport = t
+	})
+	return g.transport
+}
+
+// matchHost report whether host equals or is subdomain of one of hosts
+func matchHost(host string, hosts []string) bool {
+	for _, h := range hosts {
+		h = strings.ToLower(strings.TrimPrefix(h, "."))
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package web
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// lazySrcAttrs are attributes lazy loaders keep real image url in, in priority order
+var lazySrcAttrs = []string{"data-src", "data-lazy-src", "data-original", "data-lazy", "data-url", "data-hi-res-src", "data-full-src"}
+
+// lazySrcsetAttrs are attributes lazy loaders keep real srcset in
+var lazySrcsetAttrs = []string{"data-srcset", "data-lazy-srcset"}
+
+var placeholderRe = regexp.MustCompile(`(?i)(placeholder|blank|spacer|pixel|lazy|loading|transparent|empty|1x1|dummy)[^/]*\.(gif|png|svg|jpe?g|webp)`)
+
+// FixLazyImages promote lazy loaded image urls to src and unwrap <noscript> image fallbacks
+func FixLazyImages(n *html.Node) {
+	var noscripts []*html.Node
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "img":
+				fixLazyImage(n)
+			case "source":
+				promoteAttr(n, "srcset", lazySrcsetAttrs)
+			case "noscript":
+				noscripts = append(noscripts, n)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(n)
+	for _, ns := range noscripts {
+		unwrapNoscript(ns)
+	}
+}
+
+// fixLazyImage set real src from data-* attributes, srcset or sibling <source>
+func fixLazyImage(img *html.Node) {
+	promoteAttr(img, "srcset", lazySrcsetAttrs)
+	if !isPlaceholder(attr(img, "src")) {
+		return
+	}
+	for _, k := range lazySrcAttrs {
+		if v := strings.TrimSpace(attr(img, k)); v != "" && !isPlaceholder(v) {
+			setAttr(img, "src", v)
+			return
+		}
+	}
+	if best := bestSrcset(attr(img, "srcset")); best != "" {
+		setAttr(img, "src", best)
+		return
+	}
+	if img.Parent != nil && img.Parent.Data == "picture" {
+		for c := img.Parent.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.Data == "source" {
+				if best := bestSrcset(attr(c, "srcset")); best != "" {
+					setAttr(img, "src", best)
+					return
+				}
+			}
+		}
+	}
+}
+
+// promoteAttr set attribute key from first non empty of attrs if key is empty
+func promoteAttr(n *html.Node, key string, attrs []string) {
+	if strings.TrimSpace(attr(n, key)) != "" {
+		return
+	}
+	for _, k := range attrs {
+		if v := strings.TrimSpace(attr(n, k)); v != "" {
+			setAttr(n, key, v)
+			return
+		}
+	}
+}
+
+// isPlaceholder report whether src is empty, inline data or known placeholder image
+func isPlaceholder(src string) bool {
+	src = strings.TrimSpace(src)
+	return src == "" || strings.HasPrefix(strings.ToLower(src), "data:") || placeholderRe.MatchString(src)
+}
+
+// bestSrcset return url of widest or densest srcset candidate
+func bestSrcset(srcset string) string {
+	best, bestSize := "", -1.0
+	for _, c := range parseSrcset(srcset) {
+		size := 1.0
+		if d := c.descriptor; len(d) > 1 {
+			if v, err := strconv.ParseFloat(d[:len(d)-1], 64); err == nil {
+				size = v
+				if d[len(d)-1] == 'x' {
+					// densities are compared with widths assuming 1x is 1000px wide
+					size *= 1000
+				}
+			}
+		}
+		if size > bestSize && !isPlaceholder(c.url) {
+			best, bestSize = c.url, size
+		}
+	}
+	return best
+}
+
+// unwrapNoscript replace <noscript> containing images with its parsed content,
+// placeholder image right before it is removed
+func unwrapNoscript(ns *html.Node) {
+	if ns.Parent == nil || ns.FirstChild == nil || ns.FirstChild.Type != html.TextNode {
+		return
+	}
+	if !strings.Contains(strings.ToLower(ns.FirstChild.Data), "<img") {
+		return
+	}
+	ctx := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(ns.FirstChild.Data), ctx)
+	if err != nil {
+		return
+	}
+	prev := ns.PrevSibling
+	for prev != nil && prev.Type == html.TextNode && strings.TrimSpace(prev.Data) == "" {
+		prev = prev.PrevSibling
+	}
+	if prev != nil && prev.Type == html.ElementNode && prev.Data == "img" && isPlaceholder(attr(prev, "src")) {
+		ns.Parent.RemoveChild(prev)
+	}
+	for _, c := range nodes {
+		FixLazyImages(c)
+		ns.Parent.InsertBefore(c, ns)
+	}
+	ns.Parent.RemoveChild(ns)
+}
+
+// setAttr set or add attribute
+func setAttr(n *html.Node, key, val string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
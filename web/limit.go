@@ -0,0 +1,104 @@
+package web
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	defMaxBodySize         = 10 << 20
+	defMaxDecompressedSize = 50 << 20
+	// DefMaxNodes is default limit of nodes in parsed document
+	DefMaxNodes = 200000
+	// DefMaxDepth is default limit of nesting in parsed document
+	DefMaxDepth = 512
+)
+
+// readLimited read at most max bytes from r, larger bodies fail with ErrTooLarge
+func readLimited(r io.Reader, max int64) ([]byte, error) {
+	if max <= 0 {
+		return ioutil.ReadAll(r)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > max {
+		return nil, fmt.Errorf("%w: body exceeds %d bytes", ErrTooLarge, max)
+	}
+	return b, nil
+}
+
+// readBody read response body respecting fetcher size limits,
+// compressed bodies are decoded when fetcher asked for compression itself
+func (f *Fetcher) readBody(resp *http.Response) ([]byte, error) {
+	if f.MaxBodySize > 0 && resp.ContentLength > f.MaxBodySize {
+		return nil, fmt.Errorf("%w: content length %d exceeds %d bytes", ErrTooLarge, resp.ContentLength, f.MaxBodySize)
+	}
+	if !f.Compression {
+		return readLimited(resp.Body, f.MaxBodySize)
+	}
+	var dec io.ReadCloser
+	var err error
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return readLimited(resp.Body, f.MaxBodySize)
+	case "gzip", "x-gzip":
+		dec, err = gzip.NewReader(&capReader{r: resp.Body, max: f.MaxBodySize})
+		if err != nil {
+			return nil, err
+		}
+	case "deflate":
+		dec = flate.NewReader(&capReader{r: resp.Body, max: f.MaxBodySize})
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding: %s", resp.Header.Get("Content-Encoding"))
+	}
+	defer dec.Close()
+	return readLimited(dec, f.MaxDecompressedSize)
+}
+
+// capReader fail with ErrTooLarge after max bytes instead of silent EOF
+type capReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (c *capReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += int64(n)
+	if c.max > 0 && c.read > c.max {
+		return n, fmt.Errorf("%w: body exceeds %d bytes", ErrTooLarge, c.max)
+	}
+	return n, err
+}
+
+// CheckDOM return ErrTooLarge if document has more than maxNodes nodes
+// or deeper than maxDepth, zero disables limit
+func CheckDOM(doc *html.Node, maxNodes, maxDepth int) error {
+	nodes := 0
+	var f func(*html.Node, int) error
+	f = func(n *html.Node, depth int) error {
+		nodes++
+		if maxNodes > 0 && nodes > maxNodes {
+			return fmt.Errorf("%w: document has more than %d nodes", ErrTooLarge, maxNodes)
+		}
+		if maxDepth > 0 && depth > maxDepth {
+			return fmt.Errorf("%w: document is deeper than %d", ErrTooLarge, maxDepth)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if err := f(c, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return f(doc, 0)
+}
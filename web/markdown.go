@@ -0,0 +1,398 @@
+package web
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// mdBlockTags are rendered as separate markdown blocks
+var mdBlockTags = map[string]bool{
+	"p": true, "div": true, "blockquote": true, "pre": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "table": true, "body": true, "html": true,
+	"section": true, "article": true, "main": true, "header": true, "footer": true,
+	"figure": true, "figcaption": true, "dl": true, "dt": true, "dd": true,
+}
+
+var (
+	mdEscapeRe    = regexp.MustCompile("([\\\\`*_\\[\\]])")
+	mdNewlinesRe  = regexp.MustCompile(`\n{3,}`)
+	footnoteRe    = regexp.MustCompile(`^\[?([0-9]+|[a-z])\]?$`)
+	codeLangClass = regexp.MustCompile(`(?:^|\s)(?:language|lang)-([\w+#-]+)`)
+)
+
+// Markdown render extracted content as markdown
+func (a *Article) Markdown() string {
+	doc := a.doc
+	if doc == nil {
+		var err error
+		if doc, err = html.Parse(strings.NewReader(a.Content)); err != nil {
+			return ""
+		}
+	}
+	return RenderMarkdown(doc)
+}
+
+// RenderMarkdown render html node as markdown: headings, nested lists, blockquotes,
+// code blocks with language hints, tables, images, links and footnotes
+func RenderMarkdown(n *html.Node) string {
+	n, notes := mdFootnotes(n)
+	out := mdBlocks(n, "\n\n")
+	if notes != "" {
+		out += "\n\n" + notes
+	}
+	out = mdNewlinesRe.ReplaceAllString(out, "\n\n")
+	return strings.TrimSpace(out) + "\n"
+}
+
+// mdBlocks render children of n, inline runs become paragraphs
+func mdBlocks(n *html.Node, sep string) string {
+	var parts []string
+	var inline strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(inline.String()); s != "" {
+			parts = append(parts, s)
+		}
+		inline.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && mdBlockTags[c.Data] {
+			flush()
+			if s := mdBlock(c); strings.TrimSpace(s) != "" {
+				parts = append(parts, s)
+			}
+			continue
+		}
+		inline.WriteString(mdInline(c))
+	}
+	flush()
+	return strings.Join(parts, sep)
+}
+
+func mdBlock(n *html.Node) string {
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(n.Data[1] - '0')
+		return strings.Repeat("#", level) + " " + strings.TrimSpace(mdInlineChildren(n))
+	case "p", "dt", "figcaption":
+		return strings.TrimSpace(mdInlineChildren(n))
+	case "hr":
+		return "---"
+	case "pre":
+		return mdPre(n)
+	case "blockquote":
+		return prefixLines(mdBlocks(n, "\n\n"), "> ", "> ")
+	case "ul", "ol":
+		return mdList(n)
+	case "table":
+		return mdTable(n)
+	case "li":
+		return prefixLines(mdBlocks(n, "\n"), "- ", "  ")
+	}
+	return mdBlocks(n, "\n\n")
+}
+
+func mdList(n *html.Node) string {
+	var items []string
+	num := 1
+	if v, err := strconv.Atoi(attr(n, "start")); err == nil {
+		num = v
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != "li" {
+			continue
+		}
+		marker := "- "
+		if n.Data == "ol" {
+			marker = strconv.Itoa(num) + ". "
+			num++
+		}
+		body := mdBlocks(c, "\n")
+		items = append(items, prefixLines(body, marker, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+func mdPre(n *html.Node) string {
+	lang := ""
+	code := n
+	if c := findElement(n, "code"); c != nil {
+		code = c
+		if m := codeLangClass.FindStringSubmatch(attr(c, "class")); m != nil {
+			lang = m[1]
+		}
+	}
+	if m := codeLangClass.FindStringSubmatch(attr(n, "class")); lang == "" && m != nil {
+		lang = m[1]
+	}
+	text := strings.Trim(rawText(code), "\n")
+	fence := "```"
+	if strings.Contains(text, fence) {
+		fence = "~~~"
+	}
+	return fence + lang + "\n" + text + "\n" + fence
+}
+
+func mdTable(n *html.Node) string {
+	var rows [][]string
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "tr" {
+			var row []string
+			for _, nodes := range tableCells(n) {
+				var sb strings.Builder
+				for _, c := range nodes {
+					sb.WriteString(mdInline(c))
+				}
+				cell := spaceRe.ReplaceAllString(sb.String(), " ")
+				row = append(row, strings.Replace(strings.TrimSpace(cell), "|", "\\|", -1))
+			}
+			rows = append(rows, row)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(n)
+	cols := 0
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	if cols == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for i, row := range rows {
+		for len(row) < cols {
+			row = append(row, "")
+		}
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			sb.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// tableCells return contents of td and th cells of row tr. Run of other nodes,
+// e.g. text of cell unwrapped by sanitize, is one more cell
+func tableCells(tr *html.Node) [][]*html.Node {
+	var cells [][]*html.Node
+	var loose []*html.Node
+	flush := func() {
+		for _, c := range loose {
+			if c.Type == html.ElementNode || (c.Type == html.TextNode && strings.TrimSpace(c.Data) != "") {
+				cells = append(cells, loose)
+				break
+			}
+		}
+		loose = nil
+	}
+	for c := tr.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (c.Data == "td" || c.Data == "th") {
+			flush()
+			var nodes []*html.Node
+			for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+				nodes = append(nodes, cc)
+			}
+			cells = append(cells, nodes)
+			continue
+		}
+		loose = append(loose, c)
+	}
+	flush()
+	return cells
+}
+
+func mdInlineChildren(n *html.Node) string {
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(mdInline(c))
+	}
+	return sb.String()
+}
+
+func mdInline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return mdEscapeRe.ReplaceAllString(spaceRe.ReplaceAllString(n.Data, " "), `\$1`)
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	switch n.Data {
+	case "script", "style":
+		return ""
+	case "b", "strong":
+		return mdWrap(mdInlineChildren(n), "**")
+	case "i", "em":
+		return mdWrap(mdInlineChildren(n), "*")
+	case "del", "s", "strike":
+		return mdWrap(mdInlineChildren(n), "~~")
+	case "code", "kbd", "tt":
+		text := spaceRe.ReplaceAllString(rawText(n), " ")
+		if strings.TrimSpace(text) == "" {
+			return text
+		}
+		if strings.Contains(text, "`") {
+			return "`` " + text + " ``"
+		}
+		return "`" + text + "`"
+	case "br":
+		return "  \n"
+	case "img":
+		src := attr(n, "src")
+		if src == "" {
+			return ""
+		}
+		if title := attr(n, "title"); title != "" {
+			return fmt.Sprintf("![%s](%s %q)", attr(n, "alt"), src, title)
+		}
+		return fmt.Sprintf("![%s](%s)", attr(n, "alt"), src)
+	case "a":
+		text := strings.TrimSpace(mdInlineChildren(n))
+		href := strings.TrimSpace(attr(n, "href"))
+		if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			return text
+		}
+		if text == "" {
+			return "<" + href + ">"
+		}
+		return "[" + text + "](" + strings.Replace(href, " ", "%20", -1) + ")"
+	case "sup":
+		if label := attr(n, footnoteAttr); label != "" {
+			return "[^" + label + "]"
+		}
+		return "<sup>" + mdInlineChildren(n) + "</sup>"
+	}
+	return mdInlineChildren(n)
+}
+
+// footnoteAttr marks <sup> rendered as footnote reference
+const footnoteAttr = "data-md-footnote"
+
+// mdFootnotes find footnote references, <sup> with label like 1 or [a] wrapping
+// link to element of the same document, e.g. <sup><a href="#fn1">1</a></sup>.
+// It returns copy of n with references marked and their targets removed, and
+// markdown definitions made of targets. n is returned as is if it has no references
+func mdFootnotes(n *html.Node) (*html.Node, string) {
+	if findNode(n, func(c *html.Node) bool { _, _, ok := footnoteRef(c); return ok }) == nil {
+		return n, ""
+	}
+	n = cloneNode(n)
+	ids := map[string]*html.Node{}
+	var sups []*html.Node
+	var f func(*html.Node)
+	f = func(c *html.Node) {
+		if c.Type == html.ElementNode {
+			if id := attr(c, "id"); id != "" && ids[id] == nil {
+				ids[id] = c
+			}
+			if c.Data == "sup" {
+				sups = append(sups, c)
+			}
+		}
+		for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+			f(cc)
+		}
+	}
+	f(n)
+	var defs []string
+	defined := map[*html.Node]bool{}
+	for _, sup := range sups {
+		id, label, ok := footnoteRef(sup)
+		target := ids[id]
+		if !ok || target == nil || isAncestor(target, sup) {
+			continue
+		}
+		sup.Attr = append(sup.Attr, html.Attribute{Key: footnoteAttr, Val: label})
+		if defined[target] {
+			continue
+		}
+		defined[target] = true
+		def := strings.TrimSpace(mdBlocks(target, "\n\n"))
+		if target.Parent != nil {
+			target.Parent.RemoveChild(target)
+		}
+		defs = append(defs, prefixLines(def, "[^"+label+"]: ", "    "))
+	}
+	return n, strings.Join(defs, "\n")
+}
+
+// footnoteRef return target id and label of footnote reference n
+func footnoteRef(n *html.Node) (id, label string, ok bool) {
+	if n.Type != html.ElementNode || n.Data != "sup" {
+		return "", "", false
+	}
+	m := footnoteRe.FindStringSubmatch(strings.TrimSpace(rawText(n)))
+	a := findElement(n, "a")
+	if m == nil || a == nil {
+		return "", "", false
+	}
+	href := strings.TrimSpace(attr(a, "href"))
+	if len(href) < 2 || href[0] != '#' {
+		return "", "", false
+	}
+	return href[1:], m[1], true
+}
+
+// isAncestor report whether a contains n
+func isAncestor(a, n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p == a {
+			return true
+		}
+	}
+	return false
+}
+
+// mdWrap wrap trimmed s with marker keeping surrounding spaces outside
+func mdWrap(s, marker string) string {
+	t := strings.TrimSpace(s)
+	if t == "" {
+		return s
+	}
+	lead, trail := "", ""
+	if strings.HasPrefix(s, " ") {
+		lead = " "
+	}
+	if strings.HasSuffix(s, " ") {
+		trail = " "
+	}
+	return lead + marker + t + marker + trail
+}
+
+// prefixLines prefix first line with first and other non empty lines with rest
+func prefixLines(s, first, rest string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + l
+		case l == "":
+			lines[i] = strings.TrimRight(rest, " ")
+		default:
+			lines[i] = rest + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rawText return text of node as is
+func rawText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(rawText(c))
+	}
+	return sb.String()
+}
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+<h2>Title <em>here</em></h2>
+<p>Some <b>bold</b> text with <a href="https://example.com/x">a link</a> and a_note<sup><a href="#fn1">1</a></sup>.</p>
+<ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul>
+<blockquote><p>quote</p><p>second</p></blockquote>
+<pre><code class="language-go">func main() {
+	fmt.Println("hi")
+}</code></pre>
+<table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>2 | 3</td></tr></table>
+<p><img src="https://example.com/i.png" alt="pic"> 10 m<sup>2</sup>, H<sub>2</sub>O</p>
+<ol class="footnotes"><li id="fn1"><p>Note <em>text</em>.</p><p>More.</p></li></ol>
+</body></html>`))
+	assert.NoError(t, err)
+
+	expected := "## Title *here*\n\n" +
+		"Some **bold** text with [a link](https://example.com/x) and a\\_note[^1].\n\n" +
+		"- one\n- two\n  1. nested\n\n" +
+		"> quote\n>\n> second\n\n" +
+		"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n\n" +
+		"| a | b |\n| --- | --- |\n| 1 | 2 \\| 3 |\n\n" +
+		"![pic](https://example.com/i.png) 10 m<sup>2</sup>, H2O\n\n" +
+		"[^1]: Note *text*.\n\n    More.\n"
+	assert.Equal(t, expected, RenderMarkdown(doc))
+
+	doc, err = html.Parse(strings.NewReader(`<p>a<sup><a href="#missing">1</a></sup></p>`))
+	assert.NoError(t, err)
+	assert.Equal(t, "a<sup>[1](#missing)</sup>\n", RenderMarkdown(doc), "no definition")
+}
+
+func TestArticleMarkdown(t *testing.T) {
+	s := pageWith("", `<p>Статья со сноской<sup><a href="#fn1">1</a></sup>.</p>`+
+		`<pre><code class="language-go">fmt.Println("hi")</code></pre>`+
+		`<ol><li id="fn1">Текст сноски.</li></ol>`)
+
+	a, err := ExtractFromHTML(s, nil, WithExtractor(CETD{}))
+	assert.NoError(t, err)
+	md := a.Markdown()
+	assert.Contains(t, md, "Статья со сноской[^1].")
+	assert.Contains(t, md, "[^1]: Текст сноски.")
+	assert.Contains(t, md, "```go\nfmt.Println(\"hi\")\n```")
+}
+
+func TestArticleMarkdownTable(t *testing.T) {
+	s := pageWith("", `<table><thead><tr><th>Head A</th><th>Head B</th></tr></thead>`+
+		`<tbody><tr><td>1</td><td>2</td></tr></tbody></table>`)
+
+	a, err := ExtractFromHTML(s, nil, WithExtractor(CETD{}))
+	assert.NoError(t, err)
+	assert.Contains(t, a.Markdown(), "| Head A | Head B |\n| --- | --- |\n| 1 | 2 |")
+
+	// header cells unwrapped by custom whitelist are kept as loose text
+	a, err = ExtractFromHTML(s, nil, WithExtractor(CETD{}), WithWhitelistTags("p", "table", "tr", "td"))
+	assert.NoError(t, err)
+	assert.Contains(t, a.Markdown(), "Head B |  |\n| --- | --- |\n| 1 | 2 |")
+}
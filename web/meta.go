@@ -0,0 +1,492 @@
+package web
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Metadata is page metadata from JSON-LD, OpenGraph, Twitter cards,
+// microdata and <meta> tags, earlier sources win
+type Metadata struct {
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Authors     []Author  `json:"authors,omitempty"`
+	SiteName    string    `json:"site_name,omitempty"`
+	Published   time.Time `json:"published,omitzero"`
+	// DateSource is where Published came from: site, json-ld, meta, microdata, time, text or url
+	DateSource string `json:"date_source,omitempty"`
+	// DateConfidence is confidence of Published from 0 to 1
+	DateConfidence float64   `json:"date_confidence,omitempty"`
+	Modified       time.Time `json:"modified,omitzero"`
+	Images         []string  `json:"images,omitempty"`
+	Tags           []string  `json:"tags,omitempty"`
+	Language       string    `json:"language,omitempty"`
+	Canonical      string    `json:"canonical,omitempty"`
+}
+
+// articleTypes are JSON-LD and microdata types describing article
+var articleTypes = map[string]bool{
+	"article": true, "newsarticle": true, "blogposting": true, "reportagenewsarticle": true,
+	"analysisnewsarticle": true, "opinionnewsarticle": true, "report": true, "scholarlyarticle": true,
+	"techarticle": true, "socialmediaposting": true, "liveblogposting": true,
+}
+
+// ReadMetadata collect metadata of page, it must be called before page is cleaned
+// because JSON-LD lives in <script> tags
+func ReadMetadata(page *html.Node) *Metadata {
+	plain := readMetaTags(page, "")
+	og := readMetaTags(page, "og:")
+	m := readJSONLD(page)
+	// canonical link is preferred over og:url and JSON-LD mainEntityOfPage
+	for _, c := range []string{og.Canonical, plain.Canonical} {
+		if c != "" {
+			m.Canonical = c
+		}
+	}
+	m.merge(og)
+	m.merge(readMetaTags(page, "twitter:"))
+	m.merge(readMicrodata(page))
+	m.merge(plain)
+	return m
+}
+
+// merge fill empty fields of m by o, images and tags are joined
+func (m *Metadata) merge(o *Metadata) {
+	first := func(a *string, b string) {
+		if *a == "" {
+			*a = b
+		}
+	}
+	first(&m.Title, o.Title)
+	first(&m.Description, o.Description)
+	first(&m.SiteName, o.SiteName)
+	first(&m.Language, o.Language)
+	first(&m.Canonical, o.Canonical)
+	if m.Published.IsZero() {
+		m.Published, m.DateSource = o.Published, o.DateSource
+	}
+	if m.Modified.IsZero() {
+		m.Modified = o.Modified
+	}
+	if len(m.Authors) == 0 {
+		m.Authors = o.Authors
+	}
+	m.Images = appendUniq(m.Images, o.Images...)
+	m.Tags = appendUniq(m.Tags, o.Tags...)
+}
+
+// readMetaTags read <meta> tags with prefix, og:, twitter: or "" for
+// plain tags which also include <title>, <html lang> and <link rel=canonical>
+func readMetaTags(page *html.Node, prefix string) *Metadata {
+	m := &Metadata{}
+	if prefix == "" {
+		if n := findElement(page, "html"); n != nil {
+			m.Language = strings.TrimSpace(attr(n, "lang"))
+		}
+	}
+	title := ""
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "title":
+				if prefix == "" && title == "" {
+					title = strings.TrimSpace(nodeText(n))
+				}
+				return
+			case "link":
+				if prefix == "" && m.Canonical == "" && hasToken(attr(n, "rel"), "canonical") {
+					m.Canonical = strings.TrimSpace(attr(n, "href"))
+				}
+				return
+			case "meta":
+				readMetaTag(n, prefix, m)
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(page)
+	if m.Title == "" {
+		m.Title = title
+	}
+	return m
+}
+
+// readMetaTag fill m by one <meta> tag if its key has prefix
+func readMetaTag(n *html.Node, prefix string, m *Metadata) {
+	key := strings.ToLower(attr(n, "property"))
+	if key == "" {
+		key = strings.ToLower(attr(n, "name"))
+	}
+	val := strings.TrimSpace(attr(n, "content"))
+	if val == "" || key == "" {
+		return
+	}
+	switch {
+	case prefix == "":
+		if strings.HasPrefix(key, "og:") || strings.HasPrefix(key, "twitter:") {
+			return
+		}
+	case !strings.HasPrefix(key, prefix):
+		return
+	default:
+		key = strings.TrimPrefix(key, prefix)
+	}
+	date := func(t *time.Time) {
+		if v, ok := ParseDate(val); ok && t.IsZero() {
+			*t = v
+			if t == &m.Published {
+				m.DateSource = "meta"
+			}
+		}
+	}
+	switch key {
+	case "title":
+		if m.Title == "" {
+			m.Title = val
+		}
+	case "description":
+		if m.Description == "" {
+			m.Description = val
+		}
+	case "site_name", "application-name":
+		if m.SiteName == "" {
+			m.SiteName = val
+		}
+	case "url":
+		if prefix == "og:" && m.Canonical == "" {
+			m.Canonical = val
+		}
+	case "image", "image:src", "image:url", "image:secure_url":
+		m.Images = appendUniq(m.Images, val)
+	case "author", "article:author":
+		// og:article:author and twitter:creator are often profile urls and handles
+		if len(m.Authors) == 0 && !strings.HasPrefix(val, "http") {
+			m.Authors = []Author{{Name: val}}
+		}
+	case "article:published_time", "published_time", "date", "pubdate", "dc.date", "dc.date.issued", "dcterms.created":
+		date(&m.Published)
+	case "article:modified_time", "modified_time", "updated_time", "og:updated_time", "dcterms.modified", "last-modified":
+		date(&m.Modified)
+	case "article:tag", "tag":
+		m.Tags = appendUniq(m.Tags, val)
+	case "keywords", "news_keywords":
+		m.Tags = appendUniq(m.Tags, splitTags(val)...)
+	}
+}
+
+// readJSONLD read first article object of <script type=application/ld+json>
+func readJSONLD(page *html.Node) *Metadata {
+	m := &Metadata{}
+	var objs []map[string]interface{}
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "script" {
+			if strings.Contains(strings.ToLower(attr(n, "type")), "ld+json") && n.FirstChild != nil {
+				var v interface{}
+				if json.Unmarshal([]byte(n.FirstChild.Data), &v) == nil {
+					objs = append(objs, ldObjects(v)...)
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(page)
+	for _, o := range objs {
+		if !ldIsArticle(o) {
+			continue
+		}
+		m.Title = ldString(o["headline"])
+		if m.Title == "" {
+			m.Title = ldString(o["name"])
+		}
+		m.Description = ldString(o["description"])
+		m.Authors = ldAuthors(o["author"])
+		if p, ok := o["publisher"].(map[string]interface{}); ok {
+			m.SiteName = ldString(p["name"])
+		}
+		if t, ok := ParseDate(ldString(o["datePublished"])); ok {
+			m.Published, m.DateSource = t, "json-ld"
+		}
+		m.Modified, _ = ParseDate(ldString(o["dateModified"]))
+		m.Images = ldURLs(o["image"])
+		m.Tags = ldTags(o["keywords"])
+		m.Language = ldString(o["inLanguage"])
+		if u := ldString(o["mainEntityOfPage"]); strings.HasPrefix(u, "http") {
+			m.Canonical = u
+		}
+		break
+	}
+	return m
+}
+
+// ldObjects flatten arrays and @graph of JSON-LD value to objects
+func ldObjects(v interface{}) []map[string]interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		var res []map[string]interface{}
+		for _, i := range v {
+			res = append(res, ldObjects(i)...)
+		}
+		return res
+	case map[string]interface{}:
+		if g, ok := v["@graph"]; ok {
+			return append([]map[string]interface{}{v}, ldObjects(g)...)
+		}
+		return []map[string]interface{}{v}
+	}
+	return nil
+}
+
+// ldIsArticle report whether @type of o is article type
+func ldIsArticle(o map[string]interface{}) bool {
+	switch t := o["@type"].(type) {
+	case string:
+		return articleTypes[strings.ToLower(t)]
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok && articleTypes[strings.ToLower(s)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ldString return string value or @value of object
+func ldString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]interface{}:
+		if s, ok := v["@value"].(string); ok {
+			return strings.TrimSpace(s)
+		}
+		if s, ok := v["@id"].(string); ok {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+// ldAuthors return authors of person or organization values
+func ldAuthors(v interface{}) []Author {
+	var res []Author
+	switch v := v.(type) {
+	case string:
+		if s := strings.TrimSpace(v); s != "" && !strings.HasPrefix(s, "http") {
+			res = appendAuthor(res, Author{Name: s})
+		}
+	case map[string]interface{}:
+		a := Author{Name: ldString(v["name"]), URL: ldString(v["url"])}
+		if same := ldURLs(v["sameAs"]); a.URL == "" && len(same) > 0 {
+			a.URL = same[0]
+		}
+		if a.Name != "" {
+			res = appendAuthor(res, a)
+		}
+	case []interface{}:
+		for _, i := range v {
+			for _, a := range ldAuthors(i) {
+				res = appendAuthor(res, a)
+			}
+		}
+	}
+	return res
+}
+
+// ldURLs return urls of image values
+func ldURLs(v interface{}) []string {
+	var res []string
+	switch v := v.(type) {
+	case string:
+		res = appendUniq(res, strings.TrimSpace(v))
+	case map[string]interface{}:
+		if s := ldString(v["url"]); s != "" {
+			res = append(res, s)
+		} else if s := ldString(v["contentUrl"]); s != "" {
+			res = append(res, s)
+		}
+	case []interface{}:
+		for _, i := range v {
+			res = appendUniq(res, ldURLs(i)...)
+		}
+	}
+	return res
+}
+
+// ldTags return keywords given as comma separated string or array
+func ldTags(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return splitTags(v)
+	case []interface{}:
+		var res []string
+		for _, i := range v {
+			if s, ok := i.(string); ok {
+				res = appendUniq(res, splitTags(s)...)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+// readMicrodata read itemprop values inside first article itemscope
+func readMicrodata(page *html.Node) *Metadata {
+	m := &Metadata{}
+	scope := findNode(page, func(n *html.Node) bool {
+		if !hasAttr(n, "itemscope") {
+			return false
+		}
+		t := attr(n, "itemtype")
+		return articleTypes[strings.ToLower(t[strings.LastIndex(t, "/")+1:])]
+	})
+	if scope == nil {
+		return m
+	}
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			for _, prop := range strings.Fields(attr(c, "itemprop")) {
+				readItemprop(c, prop, m)
+			}
+			// nested items have their own properties
+			if !hasAttr(c, "itemscope") {
+				f(c)
+			}
+		}
+	}
+	f(scope)
+	return m
+}
+
+// readItemprop fill m by value of property element n
+func readItemprop(n *html.Node, prop string, m *Metadata) {
+	if prop == "author" {
+		if a := authorOf(n); a.Name != "" && len(m.Authors) == 0 {
+			m.Authors = []Author{a}
+		}
+		return
+	}
+	val := itemValue(n)
+	if prop == "publisher" {
+		// value of nested organization is its name
+		if name := findNode(n, func(c *html.Node) bool { return hasToken(attr(c, "itemprop"), "name") }); name != nil {
+			val = itemValue(name)
+		}
+	}
+	if val == "" {
+		return
+	}
+	switch prop {
+	case "headline", "name":
+		if m.Title == "" {
+			m.Title = val
+		}
+	case "description":
+		if m.Description == "" {
+			m.Description = val
+		}
+	case "publisher":
+		if m.SiteName == "" {
+			m.SiteName = val
+		}
+	case "datePublished":
+		if t, ok := ParseDate(val); ok && m.Published.IsZero() {
+			m.Published, m.DateSource = t, "microdata"
+		}
+	case "dateModified":
+		if t, ok := ParseDate(val); ok && m.Modified.IsZero() {
+			m.Modified = t
+		}
+	case "image":
+		m.Images = appendUniq(m.Images, val)
+	case "keywords":
+		m.Tags = appendUniq(m.Tags, splitTags(val)...)
+	}
+}
+
+// itemValue return microdata value of element
+func itemValue(n *html.Node) string {
+	for _, key := range []string{"content", "datetime"} {
+		if hasAttr(n, key) {
+			return strings.TrimSpace(attr(n, key))
+		}
+	}
+	switch n.Data {
+	case "img", "audio", "video", "source":
+		return strings.TrimSpace(attr(n, "src"))
+	case "a", "link":
+		return strings.TrimSpace(attr(n, "href"))
+	case "meta":
+		return ""
+	}
+	return strings.TrimSpace(spaceRe.ReplaceAllString(nodeText(n), " "))
+}
+
+// findNode return first node matching f in depth first order
+func findNode(n *html.Node, f func(*html.Node) bool) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && f(c) {
+			return c
+		}
+		if r := findNode(c, f); r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+// hasAttr report whether element has attribute
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// hasToken report whether space separated list contains token ignoring case
+func hasToken(list, token string) bool {
+	for _, t := range strings.Fields(list) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitTags split comma separated keywords
+func splitTags(s string) []string {
+	var res []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// appendUniq append not empty values missing in sl
+func appendUniq(sl []string, vals ...string) []string {
+	for _, v := range vals {
+		if v != "" && !contains(sl, v) {
+			sl = append(sl, v)
+		}
+	}
+	return sl
+}
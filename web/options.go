@@ -0,0 +1,156 @@
+package web
+
+import (
+	"github.com/dyatlov/go-readability"
+	"github.com/recoilme/readability/clean"
+	"github.com/recoilme/readability/site"
+)
+
+// Options configure readability pipeline
+type Options struct {
+	// WhitelistTags is list of tags kept in extracted content
+	WhitelistTags []string
+	// WhitelistAttrs is list of attributes kept per tag
+	WhitelistAttrs map[string][]string
+	// MinTextLength is minimal length of paragraph taken into account
+	MinTextLength int
+	// RetryLength is minimal length of article, shorter articles are extracted again with relaxed rules
+	RetryLength int
+	// MaxNodes limits number of nodes in document, 0 means no limit
+	MaxNodes int
+	// MaxDepth limits nesting of document, 0 means no limit
+	MaxDepth int
+	// Extractor finds content, Readability if nil
+	Extractor Extractor
+	// Cleaner removes boilerplate from page before extraction, nothing is removed if nil
+	Cleaner *clean.Cleaner
+	// Site is rule of page's site tried before Extractor if not nil
+	Site *site.Rule
+}
+
+// Option change Options
+type Option func(*Options)
+
+// DefaultOptions return options used when no Option is passed
+func DefaultOptions() *Options {
+	return &Options{
+		WhitelistTags: []string{"p", "a", "img", "pre", "b", "h1", "h2", "h3", "h4", "h5", "h6",
+			"blockquote", "hr", "strong", "sup", "ul", "ol", "li", "code", "table", "thead", "tbody", "tr", "th", "td"},
+		// ids of footnotes and classes of code blocks are kept for markdown
+		WhitelistAttrs: map[string][]string{
+			"img":  {"src", "title"},
+			"a":    {"href"},
+			"li":   {"id"},
+			"p":    {"id"},
+			"pre":  {"class"},
+			"code": {"class"},
+		},
+		MinTextLength: 250,
+		RetryLength:   250,
+		MaxNodes:      DefMaxNodes,
+		MaxDepth:      DefMaxDepth,
+		Cleaner:       clean.Default,
+	}
+}
+
+// WithWhitelistTags replace list of kept tags
+func WithWhitelistTags(tags ...string) Option {
+	return func(o *Options) {
+		o.WhitelistTags = tags
+	}
+}
+
+// WithTags add tags to list of kept tags
+func WithTags(tags ...string) Option {
+	return func(o *Options) {
+		for _, t := range tags {
+			if !contains(o.WhitelistTags, t) {
+				o.WhitelistTags = append(o.WhitelistTags, t)
+			}
+		}
+	}
+}
+
+// WithAttrs add kept attributes for tag
+func WithAttrs(tag string, attrs ...string) Option {
+	return func(o *Options) {
+		for _, a := range attrs {
+			if !contains(o.WhitelistAttrs[tag], a) {
+				o.WhitelistAttrs[tag] = append(o.WhitelistAttrs[tag], a)
+			}
+		}
+	}
+}
+
+// WithMinTextLength set minimal paragraph length
+func WithMinTextLength(n int) Option {
+	return func(o *Options) {
+		o.MinTextLength = n
+	}
+}
+
+// WithRetryLength set minimal article length before retry
+func WithRetryLength(n int) Option {
+	return func(o *Options) {
+		o.RetryLength = n
+	}
+}
+
+// WithDOMLimits set limits of document size
+func WithDOMLimits(maxNodes, maxDepth int) Option {
+	return func(o *Options) {
+		o.MaxNodes = maxNodes
+		o.MaxDepth = maxDepth
+	}
+}
+
+// WithExtractor set content extractor
+func WithExtractor(e Extractor) Option {
+	return func(o *Options) {
+		o.Extractor = e
+	}
+}
+
+// WithCleaner set boilerplate cleaner applied before extraction
+func WithCleaner(c *clean.Cleaner) Option {
+	return func(o *Options) {
+		o.Cleaner = c
+	}
+}
+
+// WithSite set site rule tried before generic extraction
+func WithSite(r *site.Rule) Option {
+	return func(o *Options) {
+		o.Site = r
+	}
+}
+
+func newOptions(opts []Option) *Options {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// apply options to readability document
+func (o *Options) apply(doc *readability.Document) {
+	doc.WhitelistTags = o.WhitelistTags
+	if doc.WhitelistAttrs == nil {
+		doc.WhitelistAttrs = make(map[string][]string)
+	}
+	for tag, attrs := range o.WhitelistAttrs {
+		doc.WhitelistAttrs[tag] = attrs
+	}
+	doc.MinTextLength = o.MinTextLength
+	doc.RetryLength = o.RetryLength
+}
+
+func contains(sl []string, s string) bool {
+	for _, v := range sl {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Profile is a named set of headers a fetcher pretends to be
+type Profile struct {
+	Name    string
+	Headers map[string]string
+}
+
+// Known profiles
+var (
+	YandexBot = Profile{Name: "yandexbot", Headers: map[string]string{
+		"User-Agent": "Mozilla/5.0 (compatible; YandexBot/3.0; +http://yandex.com/bots)",
+	}}
+	Googlebot = Profile{Name: "googlebot", Headers: map[string]string{
+		"User-Agent": "Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/41.0.2272.96 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+	}}
+	Desktop = Profile{Name: "desktop", Headers: map[string]string{
+		"User-Agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.14; rv:65.0) Gecko/20100101 Firefox/65.0",
+	}}
+	IPhone = Profile{Name: "iphone", Headers: map[string]string{
+		"User-Agent": "Mozilla/5.0 (iPhone; CPU iPhone OS 12_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/12.0 Mobile/15E148 Safari/604.1",
+	}}
+)
+
+// KnownProfiles is index of known profiles by name
+var KnownProfiles = map[string]Profile{
+	YandexBot.Name: YandexBot,
+	Googlebot.Name: Googlebot,
+	Desktop.Name:   Desktop,
+	IPhone.Name:    IPhone,
+}
+
+// fallbackStatus are status codes on which next profile is tried
+var fallbackStatus = map[int]bool{
+	403: true, // Forbidden
+	429: true, // Too Many Requests
+	451: true, // Unavailable For Legal Reasons
+}
+
+// profiles return profile chain for url, ua replaces chain with single profile
+func (f *Fetcher) profiles(geturl, ua string) []Profile {
+	if ua != "" {
+		return []Profile{{Name: "custom", Headers: map[string]string{"User-Agent": ua}}}
+	}
+	if len(f.HostProfiles) > 0 {
+		if u, err := url.Parse(geturl); err == nil {
+			host := strings.ToLower(u.Hostname())
+			for host != "" {
+				if p, ok := f.HostProfiles[host]; ok {
+					return p
+				}
+				i := strings.Index(host, ".")
+				if i < 0 {
+					break
+				}
+				host = host[i+1:]
+			}
+		}
+	}
+	if len(f.Profiles) == 0 {
+		return []Profile{{}}
+	}
+	return f.Profiles
+}
@@ -0,0 +1,136 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configure retries of transient fetch failures
+type RetryPolicy struct {
+	// MaxAttempts is maximum number of attempts including first one
+	MaxAttempts int
+	// MinBackoff is delay before second attempt, it doubles on every next attempt
+	MinBackoff time.Duration
+	// MaxBackoff caps delay between attempts and Retry-After
+	MaxBackoff time.Duration
+	// Jitter is fraction of delay randomized, from 0 to 1
+	Jitter float64
+	// Status is set of retryable status codes
+	Status map[int]bool
+	// Deadline limits total time of all attempts, 0 means no limit
+	Deadline time.Duration
+}
+
+// DefaultRetryPolicy return policy with 3 attempts for 5xx and network errors
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  500 * time.Millisecond,
+		MaxBackoff:  10 * time.Second,
+		Jitter:      0.2,
+		Status: map[int]bool{
+			http.StatusRequestTimeout:      true,
+			http.StatusInternalServerError: true,
+			http.StatusBadGateway:          true,
+			http.StatusServiceUnavailable:  true,
+			http.StatusGatewayTimeout:      true,
+		},
+		Deadline: 30 * time.Second,
+	}
+}
+
+// retryable report whether failed attempt may succeed on retry: status is
+// retryable by policy, attempt timed out, connection failed or was dropped
+// or DNS failure is temporary. Other errors are permanent
+func (p *RetryPolicy) retryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || errors.Is(err, ErrBlocked) {
+		return false
+	}
+	var se *HTTPStatusError
+	if errors.As(err, &se) {
+		return p.Status[se.StatusCode]
+	}
+	if errors.Is(err, ErrTimeout) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var de *net.DNSError
+	if errors.As(err, &de) {
+		return de.IsTimeout || de.IsTemporary
+	}
+	var oe *net.OpError
+	return errors.As(err, &oe)
+}
+
+// backoff return delay before attempt n (starting from 1) honoring Retry-After of resp
+func (p *RetryPolicy) backoff(n int, resp *Response) time.Duration {
+	d := p.MinBackoff << uint(n-1)
+	if d <= 0 || (p.MaxBackoff > 0 && d > p.MaxBackoff) {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	if resp != nil {
+		if ra, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			d = ra
+			if p.MaxBackoff > 0 && d > p.MaxBackoff {
+				d = p.MaxBackoff
+			}
+		}
+	}
+	return d
+}
+
+// retryAfter parse Retry-After header in seconds or http date
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(v); err == nil {
+		if sec < 0 {
+			sec = 0
+		}
+		return time.Duration(sec) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// retry call fetch while it fails with retryable errors and return number of attempts
+func (p *RetryPolicy) retry(ctx context.Context, fetch func(context.Context) (*Response, error)) (r *Response, attempts int, err error) {
+	if p.Deadline > 0 {
+		var cncl context.CancelFunc
+		ctx, cncl = context.WithTimeout(ctx, p.Deadline)
+		defer cncl()
+	}
+	for {
+		attempts++
+		r, err = fetch(ctx)
+		if attempts >= p.MaxAttempts || !p.retryable(ctx, err) {
+			return r, attempts, err
+		}
+		d := p.backoff(attempts, r)
+		if dl, ok := ctx.Deadline(); ok && time.Now().Add(d).After(dl) {
+			return r, attempts, err
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return r, attempts, err
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package web
+
+import (
+	"github.com/recoilme/readability/site"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// siteContent return document with copies of elements selected by site rule
+// sanitized by options whitelists, nil if rule selects nothing
+func siteContent(page *html.Node, o *Options) *html.Node {
+	nodes := o.Site.ContentNodes(page)
+	if len(nodes) == 0 {
+		return nil
+	}
+	div := &html.Node{Type: html.ElementNode, DataAtom: atom.Div, Data: "div"}
+	for _, n := range nodes {
+		div.AppendChild(cloneNode(n))
+	}
+	return wrapDocument(sanitize(div, o))
+}
+
+// siteFields are article fields selected by site rule
+type siteFields struct {
+	title, author, date, next string
+}
+
+// readSite select fields by site rule, it's done before generic cleaner runs
+// because fields are often outside of content
+func readSite(page *html.Node, r *site.Rule) *siteFields {
+	return &siteFields{
+		title:  r.TitleText(page),
+		author: r.AuthorText(page),
+		date:   r.DateText(page),
+		next:   r.NextURL(page),
+	}
+}
+
+// apply override article title, byline, date and next page url by not empty fields
+func (sf *siteFields) apply(a *Article) {
+	if sf.title != "" {
+		a.Title = sf.title
+	}
+	if sf.author != "" {
+		a.setAuthors([]Author{{Name: sf.author}})
+	}
+	if t, ok := ParseDate(sf.date); ok {
+		a.PublishedTime, a.DateSource, a.DateConfidence = t, "site", 1
+	}
+	if sf.next != "" {
+		a.next = resolveURL(a.FinalURL, sf.next)
+	}
+}
@@ -0,0 +1,138 @@
+package web
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// RenderText render html node as plain text: one paragraph per block element
+// separated by blank lines, list items with bullets and <pre> text kept as is
+func RenderText(n *html.Node) string {
+	out := textBlocks(n, "\n\n")
+	out = mdNewlinesRe.ReplaceAllString(out, "\n\n")
+	return strings.TrimSpace(out)
+}
+
+func textBlocks(n *html.Node, sep string) string {
+	var parts []string
+	var inline strings.Builder
+	flush := func() {
+		if s := trimLines(inline.String()); s != "" {
+			parts = append(parts, s)
+		}
+		inline.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && mdBlockTags[c.Data] {
+			flush()
+			if s := textBlock(c); strings.TrimSpace(s) != "" {
+				parts = append(parts, s)
+			}
+			continue
+		}
+		inline.WriteString(textInline(c))
+	}
+	flush()
+	return strings.Join(parts, sep)
+}
+
+func textBlock(n *html.Node) string {
+	switch n.Data {
+	case "hr":
+		return ""
+	case "pre":
+		return strings.Trim(rawText(n), "\n")
+	case "ul", "ol":
+		var items []string
+		num := 1
+		if v, err := strconv.Atoi(attr(n, "start")); err == nil {
+			num = v
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data != "li" {
+				continue
+			}
+			marker := "• "
+			if n.Data == "ol" {
+				marker = strconv.Itoa(num) + ". "
+				num++
+			}
+			items = append(items, prefixLines(textBlocks(c, "\n"), marker, strings.Repeat(" ", len([]rune(marker)))))
+		}
+		return strings.Join(items, "\n")
+	case "li":
+		return prefixLines(textBlocks(n, "\n"), "• ", "  ")
+	case "table":
+		var rows []string
+		var f func(*html.Node)
+		f = func(n *html.Node) {
+			if n.Type == html.ElementNode && n.Data == "tr" {
+				var cells []string
+				for _, nodes := range tableCells(n) {
+					var sb strings.Builder
+					for _, c := range nodes {
+						sb.WriteString(textInline(c))
+					}
+					cells = append(cells, strings.TrimSpace(spaceRe.ReplaceAllString(sb.String(), " ")))
+				}
+				rows = append(rows, strings.Join(cells, "\t"))
+				return
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				f(c)
+			}
+		}
+		f(n)
+		return strings.Join(rows, "\n")
+	}
+	return textBlocks(n, "\n\n")
+}
+
+func textInlineChildren(n *html.Node) string {
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(textInline(c))
+	}
+	return sb.String()
+}
+
+func textInline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return spaceRe.ReplaceAllString(n.Data, " ")
+	case html.ElementNode:
+		switch n.Data {
+		case "script", "style", "img":
+			return ""
+		case "br":
+			return "\n"
+		}
+		return textInlineChildren(n)
+	}
+	return ""
+}
+
+// trimLines trim spaces around every line and the whole text
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// normalizeSpace collapse whitespace of text nodes outside <pre>
+func normalizeSpace(n *html.Node) {
+	if n.Type == html.TextNode {
+		n.Data = spaceRe.ReplaceAllString(n.Data, " ")
+		return
+	}
+	if n.Type == html.ElementNode && n.Data == "pre" {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		normalizeSpace(c)
+	}
+}
@@ -0,0 +1,41 @@
+package web
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestRenderText(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+<h2>Title</h2>
+<p>First   paragraph
+ with &amp; entity.</p><p>Second<br>line</p>
+<ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul>
+<pre>  keep
+    indent</pre>
+</body></html>`))
+	assert.NoError(t, err)
+
+	expected := "Title\n\n" +
+		"First paragraph with & entity.\n\n" +
+		"Second\nline\n\n" +
+		"• one\n• two\n  1. nested\n\n" +
+		"  keep\n    indent"
+	assert.Equal(t, expected, RenderText(doc))
+}
+
+func TestArticleTextTable(t *testing.T) {
+	s := pageWith("", `<table><thead><tr><th>Head A</th><th>Head B</th></tr></thead>`+
+		`<tbody><tr><td>1</td><td>2</td></tr></tbody></table>`)
+
+	a, err := ExtractFromHTML(s, nil, WithExtractor(CETD{}))
+	assert.NoError(t, err)
+	assert.Contains(t, a.Text, "Head A\tHead B\n1\t2")
+
+	a, err = ExtractFromHTML(s, nil, WithExtractor(CETD{}), WithWhitelistTags("p", "table", "tr", "td"))
+	assert.NoError(t, err)
+	assert.Contains(t, a.Text, "Head B\n1\t2")
+}
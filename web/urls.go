@@ -0,0 +1,136 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// urlAttrs are attributes holding single url
+var urlAttrs = map[string]bool{"href": true, "src": true, "poster": true}
+
+// documentBase return url from <base href> resolved against u, or u itself
+func documentBase(page *html.Node, u *url.URL) *url.URL {
+	if page == nil {
+		return u
+	}
+	b := findElement(page, "base")
+	if b == nil {
+		return u
+	}
+	href := strings.TrimSpace(attr(b, "href"))
+	if href == "" {
+		return u
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return u
+	}
+	if u == nil {
+		if ref.IsAbs() {
+			return ref
+		}
+		return nil
+	}
+	return u.ResolveReference(ref)
+}
+
+// ResolveURLs make href, src, srcset and poster attributes of n and its descendants absolute
+func ResolveURLs(n *html.Node, base *url.URL) {
+	if base == nil {
+		return
+	}
+	if n.Type == html.ElementNode {
+		for i, a := range n.Attr {
+			switch {
+			case urlAttrs[a.Key]:
+				n.Attr[i].Val = resolveRef(base, a.Val)
+			case a.Key == "srcset":
+				n.Attr[i].Val = resolveSrcset(base, a.Val)
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		ResolveURLs(c, base)
+	}
+}
+
+// resolveRef resolve single reference, fragments and javascript/data urls are kept as is
+func resolveRef(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return ref
+	}
+	lower := strings.ToLower(ref)
+	if strings.HasPrefix(lower, "javascript:") || strings.HasPrefix(lower, "data:") {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(r).String()
+}
+
+// resolveSrcset resolve every candidate url of srcset keeping descriptors
+func resolveSrcset(base *url.URL, srcset string) string {
+	candidates := parseSrcset(srcset)
+	for i := range candidates {
+		candidates[i].url = resolveRef(base, candidates[i].url)
+	}
+	return formatSrcset(candidates)
+}
+
+type srcsetCandidate struct {
+	url        string
+	descriptor string
+}
+
+// parseSrcset split srcset to candidates as html spec does: url lasts up to
+// whitespace, commas at its end separate candidates, otherwise descriptors
+// last up to comma outside of parentheses. Commas inside urls are allowed
+func parseSrcset(srcset string) []srcsetCandidate {
+	isSpace := func(b byte) bool { return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f' }
+	var candidates []srcsetCandidate
+	for i := 0; i < len(srcset); {
+		for i < len(srcset) && (isSpace(srcset[i]) || srcset[i] == ',') {
+			i++
+		}
+		start := i
+		for i < len(srcset) && !isSpace(srcset[i]) {
+			i++
+		}
+		c := srcsetCandidate{url: srcset[start:i]}
+		if strings.HasSuffix(c.url, ",") {
+			c.url = strings.TrimRight(c.url, ",")
+		} else {
+			start, parens := i, 0
+			for ; i < len(srcset) && (srcset[i] != ',' || parens > 0); i++ {
+				switch srcset[i] {
+				case '(':
+					parens++
+				case ')':
+					parens--
+				}
+			}
+			c.descriptor = strings.Join(strings.Fields(srcset[start:i]), " ")
+		}
+		if c.url != "" {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates
+}
+
+func formatSrcset(candidates []srcsetCandidate) string {
+	parts := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if c.descriptor != "" {
+			parts = append(parts, c.url+" "+c.descriptor)
+		} else {
+			parts = append(parts, c.url)
+		}
+	}
+	return strings.Join(parts, ", ")
+}